@@ -1,13 +1,20 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"sys-monitor-report/internal/collectors"
+	"sys-monitor-report/internal/events"
+	"sys-monitor-report/internal/export"
+	"sys-monitor-report/internal/history"
 	"sys-monitor-report/internal/report"
+	"sys-monitor-report/internal/reporting"
+	"sys-monitor-report/internal/sinks"
 	"sys-monitor-report/internal/utils"
 	"syscall"
 	"time"
@@ -21,6 +28,20 @@ func main() {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
+	if err := collectors.SetPartitionFilter(config.Partitions); err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	if err := collectors.SetExpectedListeners(config.Listeners); err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExport(config, os.Args[2:]); err != nil {
+			log.Fatalf("Error exporting metrics: %v", err)
+		}
+		return
+	}
+
 	logInterval := time.Duration(config.LogInterval) * time.Second
 
 	// Graceful shutdown on quit
@@ -29,29 +50,153 @@ func main() {
 
 	report.Init()
 
+	store, err := history.NewStore(
+		filepath.Join(config.DataDir, "history"),
+		time.Duration(config.History.Interval)*time.Second,
+		time.Duration(config.History.RetentionDays)*24*time.Hour,
+	)
+	if err != nil {
+		log.Fatalf("Error opening history store: %v", err)
+	}
+
+	runLog, err := reporting.NewRunLog(filepath.Join(config.DataDir, "report-runs.jsonl"))
+	if err != nil {
+		log.Fatalf("Error opening report run log: %v", err)
+	}
+
+	scheduler, err := reporting.NewScheduler(config.Reports, config.Thresholds, store, runLog)
+	if err != nil {
+		log.Fatalf("Error configuring reports: %v", err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go scheduler.Run(done)
+
 	fmt.Println("Starting system monitor...")
 
 	go func() {
 		http.Handle("/metrics", promhttp.Handler())
+		http.Handle("/api/v1/export", export.Handler(store))
+		http.Handle("/api/v1/listeners", collectors.ListenersHandler())
 		fmt.Println("Prometheus metrics available at http://localhost:8080/metrics")
+		fmt.Println("History export available at http://localhost:8080/api/v1/export")
+		fmt.Println("Listening ports available at http://localhost:8080/api/v1/listeners")
 		log.Fatal(http.ListenAndServe(":8080", nil))
 	}()
 
+	outputs, err := sinks.Open(config)
+	if err != nil {
+		log.Fatalf("Error configuring outputs: %v", err)
+	}
+
+	eventOutputs, err := sinks.OpenEventSinks(config)
+	if err != nil {
+		log.Fatalf("Error configuring event outputs: %v", err)
+	}
+	var logOutputs []sinks.LogSink
+	for _, output := range eventOutputs {
+		events.Subscribe(output.Event)
+		if logOutput, ok := output.(sinks.LogSink); ok {
+			logOutputs = append(logOutputs, logOutput)
+		}
+	}
+	if len(logOutputs) > 0 {
+		restore, err := utils.TeeStdout(func(line string) {
+			for _, output := range logOutputs {
+				output.Log(line)
+			}
+		})
+		if err != nil {
+			log.Fatalf("Error capturing agent output: %v", err)
+		}
+		defer restore()
+	}
+
+	alerts := events.NewAlertEvaluator(config.Thresholds)
+
 	ticker := time.NewTicker(logInterval)
 	defer ticker.Stop()
 
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				collectors.CollectSystemMetrics(config)
-			case <-stop:
-				fmt.Println("Shutting down system monitor...")
-				return
+	for {
+		select {
+		case <-ticker.C:
+			collectors.CollectSystemMetrics(config)
+
+			snap, err := history.Capture(report.Registry, time.Now())
+			if err != nil {
+				fmt.Printf("Error capturing metrics: %v\n", err)
+				continue
+			}
+			if err := store.Append(snap); err != nil {
+				fmt.Printf("Error storing metrics: %v\n", err)
+			}
+			alerts.Evaluate(snap)
+			for _, output := range outputs {
+				output.Write(snap)
 			}
+		case <-stop:
+			fmt.Println("Shutting down system monitor...")
+			for _, output := range outputs {
+				if err := output.Close(); err != nil {
+					fmt.Printf("Error closing output: %v\n", err)
+				}
+			}
+			for _, output := range eventOutputs {
+				if err := output.Close(); err != nil {
+					fmt.Printf("Error closing event output: %v\n", err)
+				}
+			}
+			fmt.Println("\nSystem monitor terminated.")
+			return
 		}
-	}()
+	}
+}
+
+// runExport writes stored samples to stdout or a file, e.g.
+// main export -from 2026-01-01 -metric "cpu_*" -format csv-long -o cpu.csv
+func runExport(config utils.Config, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	from := flags.String("from", "24h", "Start time: RFC 3339, date, Unix seconds or a duration ago")
+	to := flags.String("to", "", "End time, defaults to now")
+	metrics := flags.String("metric", "", "Comma-separated metric names or patterns, e.g. cpu_*")
+	format := flags.String("format", export.FormatCSV, "csv, csv-long or jsonl")
+	output := flags.String("o", "", "Output file, defaults to stdout")
+	flags.Parse(args)
+
+	now := time.Now()
+	req := export.Request{
+		To:      now,
+		Metrics: export.SplitList(*metrics),
+		Format:  *format,
+	}
+
+	var err error
+	if req.From, err = export.ParseTime(*from, now); err != nil {
+		return err
+	}
+	if *to != "" {
+		if req.To, err = export.ParseTime(*to, now); err != nil {
+			return err
+		}
+	}
+	if req.To.Before(req.From) {
+		return fmt.Errorf("export end %s is before its start %s", req.To.Format(time.RFC3339), req.From.Format(time.RFC3339))
+	}
+
+	// Read-only, so exporting never prunes or compresses the agent's history
+	store, err := history.OpenStoreReadOnly(filepath.Join(config.DataDir, "history"))
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
 
-	<-stop
-	fmt.Println("\nSystem monitor terminated.")
+	return export.Export(out, store, req)
 }
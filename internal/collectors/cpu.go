@@ -2,8 +2,11 @@ package collectors
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
 	"sys-monitor-report/internal/report"
-	"time"
 
 	"github.com/shirou/gopsutil/v4/cpu"
 )
@@ -43,38 +46,101 @@ func FormatCPUData(cpuData *CPUData) string {
 	return formattedData
 }
 
-// GetCPUData collects overall, per-core, and top process CPU usage
+var (
+	cpuUsageMu   sync.Mutex
+	lastCPUUsage = make(map[string]cpu.TimesStat) // Previous reading by cpu label
+)
+
+// GetCPUData collects overall and per-core CPU usage since the previous call.
+// It does not block: the first call only records the CPU times, so usage is
+// reported from the second call on. Cores going offline are dropped and
+// cores coming online are reported from their second reading.
 func GetCPUData() (CPUData, error) {
 	var data CPUData
 
-	// Total CPU usage
-	totalUsage, err := cpu.Percent(time.Second, false)
+	total, err := cpu.Times(false)
 	if err != nil {
-		return data, fmt.Errorf("error collecting total CPU usage: %v\n", err)
+		return data, fmt.Errorf("error collecting total CPU times: %v", err)
 	}
-	if len(totalUsage) > 0 {
-		data.TotalUsage = totalUsage[0]
+	perCore, err := cpu.Times(true)
+	if err != nil {
+		return data, fmt.Errorf("error collecting per-core CPU times: %v", err)
+	}
+
+	cpuUsageMu.Lock()
+	defer cpuUsageMu.Unlock()
 
-		report.OverallCPUUsage.Set(data.TotalUsage)
+	// Total CPU usage
+	if len(total) > 0 {
+		if usage, ok := cpuUsageSince("total", total[0]); ok {
+			data.TotalUsage = usage
+			report.OverallCPUUsage.Set(data.TotalUsage)
+		}
 	}
 
 	// Per-Core Usage
-	perCoreUsage, err := cpu.Percent(time.Second, true)
-	if err != nil {
-		return data, fmt.Errorf("error collecting per-core CPU usage: %v\n", err)
-	}
-	data.PerCore = perCoreUsage
+	online := map[string]bool{"total": true}
+	for i, times := range perCore {
+		label := coreLabel(times.CPU, i)
+		online[label] = true
 
-	for i, usage := range perCoreUsage {
-		report.PerCoreCPUUsage.WithLabelValues(fmt.Sprintf("core_%d", i+1)).Set(usage)
+		if usage, ok := cpuUsageSince(label, times); ok {
+			data.PerCore = append(data.PerCore, usage)
+			report.PerCoreCPUUsage.WithLabelValues(label).Set(usage)
+		}
+	}
+	for label := range lastCPUUsage {
+		if !online[label] {
+			delete(lastCPUUsage, label)
+			report.PerCoreCPUUsage.DeleteLabelValues(label)
+		}
 	}
 
 	// Number of Cores
-	numCores, err := cpu.Counts(true)
-	if err != nil {
-		return data, fmt.Errorf("error collecting number of CPU cores: %v\n", err)
-	}
-	data.NumCores = int32(numCores)
+	data.NumCores = int32(len(perCore))
 
 	return data, nil
 }
+
+// cpuUsageSince returns the busy share of the time elapsed since the previous
+// reading of the same cpu and records the new reading. It returns false on
+// the first reading and when the times went backwards, e.g. after a core was
+// brought back online.
+func cpuUsageSince(label string, times cpu.TimesStat) (float64, bool) {
+	previous, seen := lastCPUUsage[label]
+	lastCPUUsage[label] = times
+	if !seen {
+		return 0, false
+	}
+
+	seconds := cpuModeSeconds(times)
+	last := cpuModeSeconds(previous)
+
+	// Guest time is already part of user time
+	var elapsed float64
+	for _, mode := range cpuModes {
+		if mode == "guest" {
+			continue
+		}
+		delta := seconds[mode] - last[mode]
+		if delta < 0 {
+			return 0, false
+		}
+		elapsed += delta
+	}
+	if elapsed == 0 {
+		return 0, false
+	}
+
+	idle := seconds["idle"] - last["idle"] + seconds["iowait"] - last["iowait"]
+	return math.Max(0, math.Min(100, (elapsed-idle)/elapsed*100)), true
+}
+
+// coreLabel names a core after its kernel number, e.g. cpu3 is core_4, so
+// labels stay stable when other cores go offline
+func coreLabel(name string, index int) string {
+	if n, err := strconv.Atoi(strings.TrimPrefix(name, "cpu")); err == nil {
+		return fmt.Sprintf("core_%d", n+1)
+	}
+	return fmt.Sprintf("core_%d", index+1)
+}
@@ -0,0 +1,147 @@
+package collectors
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sys-monitor-report/internal/events"
+	"sys-monitor-report/internal/report"
+)
+
+// CPUFrequencyData holds the frequency and throttle counters of one core
+type CPUFrequencyData struct {
+	Core       string  // core_1, core_2, ... as in PerCoreCPUUsage
+	CurrentMHz float64 // 0 if cpufreq is not available
+	MinMHz     float64
+	MaxMHz     float64
+
+	CoreThrottles    uint64 // Times the core was throttled since boot
+	PackageThrottles uint64 // Times its package was throttled since boot
+}
+
+var (
+	throttleMu    sync.Mutex
+	lastThrottles = make(map[string]uint64) // Last count by core and type
+)
+
+// GetCPUFrequencyData reads cpufreq and thermal_throttle from sysfs for every
+// core. Cores without either, e.g. in most VMs, are skipped. An increase in a
+// throttle counter is published as a spike event.
+func GetCPUFrequencyData() ([]CPUFrequencyData, error) {
+	dirs, err := filepath.Glob(hostSys("devices", "system", "cpu", "cpu[0-9]*"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing CPUs: %v", err)
+	}
+
+	var data []CPUFrequencyData
+	for _, dir := range dirs {
+		index, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "cpu"))
+		if err != nil {
+			continue
+		}
+
+		core := CPUFrequencyData{Core: fmt.Sprintf("core_%d", index+1)}
+		found := false
+
+		for _, freq := range []struct {
+			file  string
+			value *float64
+		}{
+			{"scaling_cur_freq", &core.CurrentMHz},
+			{"cpuinfo_min_freq", &core.MinMHz},
+			{"cpuinfo_max_freq", &core.MaxMHz},
+		} {
+			khz, err := readSysfsUint(filepath.Join(dir, "cpufreq", freq.file))
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return data, fmt.Errorf("error reading %s of %s: %v", freq.file, core.Core, err)
+			}
+			*freq.value = float64(khz) / 1000
+			found = true
+		}
+
+		for _, throttle := range []struct {
+			file  string
+			value *uint64
+		}{
+			{"core_throttle_count", &core.CoreThrottles},
+			{"package_throttle_count", &core.PackageThrottles},
+		} {
+			count, err := readSysfsUint(filepath.Join(dir, "thermal_throttle", throttle.file))
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return data, fmt.Errorf("error reading %s of %s: %v", throttle.file, core.Core, err)
+			}
+			*throttle.value = count
+			found = true
+		}
+
+		if found {
+			data = append(data, core)
+		}
+	}
+
+	// Numeric order, so core_2 comes before core_10
+	sort.Slice(data, func(i, j int) bool {
+		return len(data[i].Core) < len(data[j].Core) ||
+			len(data[i].Core) == len(data[j].Core) && data[i].Core < data[j].Core
+	})
+
+	// Update Prometheus Metrics
+	for _, core := range data {
+		if core.MaxMHz > 0 {
+			report.CPUFrequency.WithLabelValues(core.Core, "current").Set(core.CurrentMHz)
+			report.CPUFrequency.WithLabelValues(core.Core, "min").Set(core.MinMHz)
+			report.CPUFrequency.WithLabelValues(core.Core, "max").Set(core.MaxMHz)
+		}
+		updateThrottleCount(core, "core", core.CoreThrottles)
+		updateThrottleCount(core, "package", core.PackageThrottles)
+	}
+
+	return data, nil
+}
+
+// updateThrottleCount adds new throttle events to the counter and publishes
+// them. The first reading is counted but not published, as it covers the
+// time since boot.
+func updateThrottleCount(core CPUFrequencyData, kind string, count uint64) {
+	throttleMu.Lock()
+	defer throttleMu.Unlock()
+
+	key := core.Core + "/" + kind
+	last, seen := lastThrottles[key]
+	lastThrottles[key] = count
+	if seen && count <= last {
+		return
+	}
+
+	delta := count - last
+	report.CPUThrottles.WithLabelValues(core.Core, kind).Add(float64(delta))
+	if !seen {
+		return
+	}
+
+	message := fmt.Sprintf("%s %s throttled %d times", core.Core, kind, delta)
+	if core.MaxMHz > 0 {
+		message += fmt.Sprintf(", running at %.0f of %.0f MHz", core.CurrentMHz, core.MaxMHz)
+	}
+	fmt.Println(message)
+
+	events.Publish(events.Event{
+		Kind:      events.KindSpike,
+		Severity:  events.SeverityWarning,
+		Collector: "cpufreq",
+		Metric:    fmt.Sprintf(`cpu_throttle_events_total{core="%s",type="%s"}`, core.Core, kind),
+		Value:     float64(delta),
+		Message:   message,
+	})
+}
@@ -0,0 +1,129 @@
+package collectors
+
+import (
+	"fmt"
+	"sync"
+	"sys-monitor-report/internal/report"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+)
+
+// cpuModes are the modes reported by CPU time metrics, in /proc/stat order
+var cpuModes = []string{"user", "nice", "system", "idle", "iowait", "irq", "softirq", "steal", "guest"}
+
+// CPUTimesData holds the time spent in each mode, in seconds since boot, and
+// the share of each mode since the previous reading
+type CPUTimesData struct {
+	Total   map[string]float64
+	PerCore []map[string]float64
+
+	// Percentages are empty on the first reading
+	TotalPercent   map[string]float64
+	PerCorePercent []map[string]float64
+}
+
+var (
+	cpuTimesMu   sync.Mutex
+	lastCPUTimes = make(map[string]cpu.TimesStat) // Previous reading by cpu label
+)
+
+// GetCPUTimes collects the CPU time breakdown by mode, in total and per core
+func GetCPUTimes() (CPUTimesData, error) {
+	var data CPUTimesData
+
+	total, err := cpu.Times(false)
+	if err != nil {
+		return data, fmt.Errorf("error collecting total CPU times: %v", err)
+	}
+	perCore, err := cpu.Times(true)
+	if err != nil {
+		return data, fmt.Errorf("error collecting per-core CPU times: %v", err)
+	}
+
+	cpuTimesMu.Lock()
+	defer cpuTimesMu.Unlock()
+
+	if len(total) > 0 {
+		data.Total, data.TotalPercent = updateCPUTimes("total", total[0])
+	}
+	online := map[string]bool{"total": true}
+	for i, times := range perCore {
+		label := coreLabel(times.CPU, i)
+		online[label] = true
+
+		seconds, percent := updateCPUTimes(label, times)
+		data.PerCore = append(data.PerCore, seconds)
+		data.PerCorePercent = append(data.PerCorePercent, percent)
+	}
+
+	// Cores taken offline stop exporting their last values
+	for label := range lastCPUTimes {
+		if online[label] {
+			continue
+		}
+		delete(lastCPUTimes, label)
+		for _, mode := range cpuModes {
+			report.CPUSeconds.DeleteLabelValues(label, mode)
+			report.CPUModePercentage.DeleteLabelValues(label, mode)
+		}
+	}
+
+	return data, nil
+}
+
+// updateCPUTimes adds the time spent in each mode since the previous reading
+// to the counters and sets the percentage of each mode in that interval
+func updateCPUTimes(label string, times cpu.TimesStat) (map[string]float64, map[string]float64) {
+	seconds := cpuModeSeconds(times)
+
+	previous, seen := lastCPUTimes[label]
+	lastCPUTimes[label] = times
+	if !seen {
+		// The kernel counts from boot, so the first reading is added in full
+		for _, mode := range cpuModes {
+			report.CPUSeconds.WithLabelValues(label, mode).Add(seconds[mode])
+		}
+		return seconds, nil
+	}
+
+	last := cpuModeSeconds(previous)
+	percent := make(map[string]float64, len(cpuModes))
+
+	// Guest time is already part of user time, so it is left out of the
+	// elapsed total
+	var elapsed float64
+	for _, mode := range cpuModes {
+		if mode != "guest" {
+			elapsed += seconds[mode] - last[mode]
+		}
+	}
+
+	for _, mode := range cpuModes {
+		delta := seconds[mode] - last[mode]
+		if delta < 0 {
+			continue
+		}
+		report.CPUSeconds.WithLabelValues(label, mode).Add(delta)
+
+		if elapsed > 0 {
+			percent[mode] = delta / elapsed * 100
+			report.CPUModePercentage.WithLabelValues(label, mode).Set(percent[mode])
+		}
+	}
+
+	return seconds, percent
+}
+
+func cpuModeSeconds(times cpu.TimesStat) map[string]float64 {
+	return map[string]float64{
+		"user":    times.User,
+		"nice":    times.Nice,
+		"system":  times.System,
+		"idle":    times.Idle,
+		"iowait":  times.Iowait,
+		"irq":     times.Irq,
+		"softirq": times.Softirq,
+		"steal":   times.Steal,
+		"guest":   times.Guest + times.GuestNice,
+	}
+}
@@ -2,9 +2,17 @@ package collectors
 
 import (
 	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"sys-monitor-report/internal/report"
+	"sys-monitor-report/internal/utils"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/shirou/gopsutil/v4/disk"
 )
 
@@ -16,38 +24,151 @@ type PartitionData struct {
 	Total       uint64   // Total space in bytes
 	Used        uint64   // Used space in bytes
 	Free        uint64   // Free space in bytes
+
+	InodesTotal       uint64 // 0 on file systems without fixed inode tables, e.g. btrfs
+	InodesUsed        uint64
+	InodesFree        uint64
+	InodesUsedPercent float64
+
+	GrowthRate float64       // Used space growth in bytes per second over the forecast window
+	FullIn     time.Duration // Predicted time until the free space runs out, 0 if not filling up
+}
+
+// partitionFilter holds the compiled patterns of utils.PartitionsConfig
+type partitionFilter struct {
+	includeFSTypes, excludeFSTypes         *regexp.Regexp
+	includeDevices, excludeDevices         *regexp.Regexp
+	includeMountpoints, excludeMountpoints *regexp.Regexp
+}
+
+// partFilter is nil until SetPartitionFilter is called, reporting every partition
+var partFilter *partitionFilter
+
+// SetPartitionFilter compiles the partition include and exclude patterns
+func SetPartitionFilter(cfg utils.PartitionsConfig) error {
+	f := &partitionFilter{}
+	for _, p := range []struct {
+		name     string
+		patterns []string
+		re       **regexp.Regexp
+	}{
+		{"include_fstypes", cfg.IncludeFSTypes, &f.includeFSTypes},
+		{"exclude_fstypes", cfg.ExcludeFSTypes, &f.excludeFSTypes},
+		{"include_devices", cfg.IncludeDevices, &f.includeDevices},
+		{"exclude_devices", cfg.ExcludeDevices, &f.excludeDevices},
+		{"include_mountpoints", cfg.IncludeMountpoints, &f.includeMountpoints},
+		{"exclude_mountpoints", cfg.ExcludeMountpoints, &f.excludeMountpoints},
+	} {
+		re, err := compilePatterns(p.patterns)
+		if err != nil {
+			return fmt.Errorf("invalid partitions.%s pattern: %v", p.name, err)
+		}
+		*p.re = re
+	}
+
+	partFilter = f
+	return nil
+}
+
+// compilePatterns joins the patterns into one regexp matching whole values,
+// or returns nil if there are none
+func compilePatterns(patterns []string) (*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	for _, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, err
+		}
+	}
+	return regexp.Compile("^(?:" + strings.Join(patterns, "|") + ")$")
 }
 
-// GetPartitionData retrieves grouped disk usage statistics
+// allows reports whether a partition passes the include and exclude patterns
+func (f *partitionFilter) allows(part disk.PartitionStat) bool {
+	if f == nil {
+		return true
+	}
+	for _, check := range []struct {
+		include, exclude *regexp.Regexp
+		value            string
+	}{
+		{f.includeFSTypes, f.excludeFSTypes, part.Fstype},
+		{f.includeDevices, f.excludeDevices, part.Device},
+		{f.includeMountpoints, f.excludeMountpoints, part.Mountpoint},
+	} {
+		if check.include != nil && !check.include.MatchString(check.value) {
+			return false
+		}
+		if check.exclude != nil && check.exclude.MatchString(check.value) {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	partitionMu     sync.Mutex
+	lastMountpoints = make(map[string][]string) // Exported mountpoints by device, measured one first
+)
+
+// GetPartitionData retrieves grouped disk usage statistics. Partitions are
+// filtered by the patterns set with SetPartitionFilter. A device mounted more
+// than once, e.g. through bind mounts, is measured at its shortest mountpoint.
+// Metrics of partitions that are unmounted or no longer selected are deleted.
 func GetPartitionData() ([]PartitionData, error) {
 	partitions, err := disk.Partitions(false)
 	if err != nil {
 		return nil, fmt.Errorf("error collecting disk partitions: %v", err)
 	}
 
+	var selected []disk.PartitionStat
+	for _, part := range partitions {
+		if partFilter.allows(part) {
+			selected = append(selected, part)
+		}
+	}
+
+	// Visit the mounts of each device in a fixed order so the same one is
+	// measured every cycle, whatever order the mount table lists them in
+	sort.Slice(selected, func(i, j int) bool {
+		a, b := selected[i].Mountpoint, selected[j].Mountpoint
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+
 	partitionMap := make(map[string]*PartitionData)
 
 	// Iterate over each partition
-	for _, part := range partitions {
+	for _, part := range selected {
+		// Group by Device
+		if data, exists := partitionMap[part.Device]; exists {
+			// Bind mounts report the usage of the device already measured
+			data.Mountpoints = append(data.Mountpoints, part.Mountpoint)
+			report.PartitionMountpoints.WithLabelValues(part.Device, part.Mountpoint).Set(1)
+			continue
+		}
+
 		usage, err := disk.Usage(part.Mountpoint)
 		if err != nil {
 			fmt.Printf("Error retrieving usage for %s: %v\n", part.Mountpoint, err)
 			continue
 		}
 
-		// Group by Device
-		if _, exists := partitionMap[part.Device]; !exists {
-			partitionMap[part.Device] = &PartitionData{
-				Device:      part.Device,
-				Mountpoints: []string{part.Mountpoint},
-				Filesystem:  part.Fstype,
-				Total:       usage.Total,
-				Used:        usage.Used,
-				Free:        usage.Free,
-			}
-		} else {
-			// Append additional mount points for the same device
-			partitionMap[part.Device].Mountpoints = append(partitionMap[part.Device].Mountpoints, part.Mountpoint)
+		partitionMap[part.Device] = &PartitionData{
+			Device:      part.Device,
+			Mountpoints: []string{part.Mountpoint},
+			Filesystem:  part.Fstype,
+			Total:       usage.Total,
+			Used:        usage.Used,
+			Free:        usage.Free,
+
+			InodesTotal:       usage.InodesTotal,
+			InodesUsed:        usage.InodesUsed,
+			InodesFree:        usage.InodesFree,
+			InodesUsedPercent: usage.InodesUsedPercent,
 		}
 
 		// Update Prometheus Metrics
@@ -62,6 +183,16 @@ func GetPartitionData() ([]PartitionData, error) {
 
 		report.PartitionMountpoints.WithLabelValues(part.Device, part.Mountpoint).Set(1)
 
+		if usage.InodesTotal > 0 {
+			report.PartitionInodes.WithLabelValues(part.Device, part.Mountpoint, "total").
+				Set(float64(usage.InodesTotal))
+			report.PartitionInodes.WithLabelValues(part.Device, part.Mountpoint, "used").
+				Set(float64(usage.InodesUsed))
+			report.PartitionInodes.WithLabelValues(part.Device, part.Mountpoint, "free").
+				Set(float64(usage.InodesFree))
+			report.PartitionInodes.WithLabelValues(part.Device, part.Mountpoint, "used_percent").
+				Set(usage.InodesUsedPercent)
+		}
 	}
 
 	// Convert map to slice
@@ -70,13 +201,67 @@ func GetPartitionData() ([]PartitionData, error) {
 		partitionData = append(partitionData, *data)
 	}
 
+	sort.Slice(partitionData, func(i, j int) bool {
+		return partitionData[i].Device < partitionData[j].Device
+	})
+
+	deletePartitionMetrics(partitionData)
+	forecastDiskFull(partitionData, time.Now())
+
 	return partitionData, nil
 }
 
+// deletePartitionMetrics deletes the series of devices and mountpoints that
+// were exported by the previous call but are missing from partitions
+func deletePartitionMetrics(partitions []PartitionData) {
+	partitionMu.Lock()
+	defer partitionMu.Unlock()
+
+	current := make(map[string]PartitionData, len(partitions))
+	for _, part := range partitions {
+		current[part.Device] = part
+	}
+
+	for device, mountpoints := range lastMountpoints {
+		part, exists := current[device]
+		if !exists {
+			report.PartitionSpace.DeletePartialMatch(prometheus.Labels{"device": device})
+			report.PartitionMountpoints.DeletePartialMatch(prometheus.Labels{"device": device})
+			report.PartitionInodes.DeletePartialMatch(prometheus.Labels{"device": device})
+			continue
+		}
+
+		for i, mountpoint := range mountpoints {
+			if !slices.Contains(part.Mountpoints, mountpoint) {
+				report.PartitionMountpoints.DeleteLabelValues(device, mountpoint)
+			}
+			// Inodes are only exported for the measured mountpoint
+			if i == 0 && (mountpoint != part.Mountpoints[0] || part.InodesTotal == 0) {
+				report.PartitionInodes.DeletePartialMatch(prometheus.Labels{"device": device, "mount": mountpoint})
+			}
+		}
+	}
+
+	lastMountpoints = make(map[string][]string, len(partitions))
+	for _, part := range partitions {
+		lastMountpoints[part.Device] = part.Mountpoints
+	}
+}
+
+// DiskIOData holds the activity of one block device over an interval
 type DiskIOData struct {
 	Device     string
-	ReadSpeed  float64
-	WriteSpeed float64
+	ReadSpeed  float64 // Bytes per second
+	WriteSpeed float64 // Bytes per second
+
+	ReadIOPS        float64 // Completed reads per second
+	WriteIOPS       float64 // Completed writes per second
+	ReadMergedIOPS  float64 // Adjacent reads merged by the scheduler per second
+	WriteMergedIOPS float64 // Adjacent writes merged by the scheduler per second
+	ReadAwait       float64 // Average time per read, queueing included, in milliseconds
+	WriteAwait      float64 // Average time per write, queueing included, in milliseconds
+	Utilization     float64 // Share of the interval with I/O in flight (%)
+	QueueSize       float64 // Average number of requests in flight
 }
 
 func FormatDiskIOSpeeds(diskData *[]DiskIOData) string {
@@ -113,41 +298,155 @@ func DisplayDiskIOSpeeds(diskData *[]DiskIOData) {
 	}
 }
 
-func GetDiskIOSpeeds(interval time.Duration) ([]DiskIOData, error) {
-	initialStats, err := disk.IOCounters()
+var (
+	diskIOMu       sync.Mutex
+	lastDiskIO     map[string]disk.IOCountersStat
+	lastDiskIOTime time.Time
+)
+
+// GetDiskIOSpeeds measures throughput, IOPS, latency, utilisation and queue
+// size of every block device since the previous call. It does not block: the
+// first call, and the first call after a device appears or its counters are
+// reset, only records the counters. Metrics of removed devices are deleted.
+func GetDiskIOSpeeds() ([]DiskIOData, error) {
+	stats, err := disk.IOCounters()
 	if err != nil {
-		return nil, fmt.Errorf("error collecting initial disk I/O stats: %v\n", err)
+		return nil, fmt.Errorf("error collecting disk I/O stats: %v", err)
 	}
+	now := time.Now()
 
-	time.Sleep(interval)
+	diskIOMu.Lock()
+	defer diskIOMu.Unlock()
 
-	finalStats, err := disk.IOCounters()
-	if err != nil {
-		return nil, fmt.Errorf("error collecting final disk I/O stats: %v\n", err)
-	}
+	elapsed := now.Sub(lastDiskIOTime).Seconds()
 
 	// Calculate Speeds
 	var ioData []DiskIOData
-	for device, initial := range initialStats {
-		if final, exists := finalStats[device]; exists {
-			readSpeed := float64(final.ReadBytes-initial.ReadBytes) / interval.Seconds()
-			writeSpeed := float64(final.WriteBytes-initial.WriteBytes) / interval.Seconds()
+	for device, final := range stats {
+		initial, seen := lastDiskIO[device]
+		if !seen || elapsed <= 0 {
+			continue
+		}
 
-			ioData = append(ioData, DiskIOData{
-				Device:     device,
-				ReadSpeed:  readSpeed,
-				WriteSpeed: writeSpeed,
-			})
+		data, ok := diskIOStats(device, initial, final, elapsed)
+		if !ok {
+			continue
+		}
+		ioData = append(ioData, data)
+
+		// Update Prometheus Data
+		report.DiskIOReadSpeed.WithLabelValues(device).Set(data.ReadSpeed / 1e6)
+		report.DiskIOWriteSpeed.WithLabelValues(device).Set(data.WriteSpeed / 1e6)
+		report.DiskIOOps.WithLabelValues(device, "read").Set(data.ReadIOPS)
+		report.DiskIOOps.WithLabelValues(device, "write").Set(data.WriteIOPS)
+		report.DiskIOOps.WithLabelValues(device, "read_merged").Set(data.ReadMergedIOPS)
+		report.DiskIOOps.WithLabelValues(device, "write_merged").Set(data.WriteMergedIOPS)
+		report.DiskIOAwait.WithLabelValues(device, "read").Set(data.ReadAwait)
+		report.DiskIOAwait.WithLabelValues(device, "write").Set(data.WriteAwait)
+		report.DiskIOUtilization.WithLabelValues(device).Set(data.Utilization)
+		report.DiskIOQueueSize.WithLabelValues(device).Set(data.QueueSize)
+	}
 
-			// Update Prometheus Data
-			report.DiskIOReadSpeed.WithLabelValues(device).Set(readSpeed / 1e6)
-			report.DiskIOWriteSpeed.WithLabelValues(device).Set(writeSpeed / 1e6)
+	for device := range lastDiskIO {
+		if _, exists := stats[device]; !exists {
+			deleteDiskIOMetrics(device)
 		}
 	}
 
+	lastDiskIO = stats
+	lastDiskIOTime = now
+
+	sort.Slice(ioData, func(i, j int) bool {
+		return ioData[i].Device < ioData[j].Device
+	})
+
 	return ioData, nil
 }
 
+func deleteDiskIOMetrics(device string) {
+	report.DiskIOReadSpeed.DeleteLabelValues(device)
+	report.DiskIOWriteSpeed.DeleteLabelValues(device)
+	report.DiskIOOps.DeletePartialMatch(prometheus.Labels{"device": device})
+	report.DiskIOAwait.DeletePartialMatch(prometheus.Labels{"device": device})
+	report.DiskIOUtilization.DeleteLabelValues(device)
+	report.DiskIOQueueSize.DeleteLabelValues(device)
+}
+
+// diskCounterWidth is the range of the /proc/diskstats counters on 32-bit
+// kernels, where they are unsigned longs
+const diskCounterWidth = 1 << 32
+
+// counterDelta returns how much a counter grew, allowing for one wraparound
+// of a counter of the given width. It returns false if the counter went
+// backwards from beyond that width, which can only be a reset.
+func counterDelta(last, current, width uint64) (uint64, bool) {
+	if current >= last {
+		return current - last, true
+	}
+	if last < width {
+		return width - last + current, true
+	}
+	return 0, false
+}
+
+// diskIOStats derives per-second rates and averages from two readings of a
+// device's counters taken the given number of seconds apart. Times in
+// /proc/diskstats are in milliseconds, the same metrics iostat reports. It
+// returns false if the counters were reset between the readings, e.g.
+// because the device was removed and added again.
+func diskIOStats(device string, initial, final disk.IOCountersStat, seconds float64) (DiskIOData, bool) {
+	data := DiskIOData{Device: device}
+
+	valid := true
+	delta := func(last, current, width uint64) float64 {
+		d, ok := counterDelta(last, current, width)
+		valid = valid && ok
+		return float64(d)
+	}
+
+	reads := delta(initial.ReadCount, final.ReadCount, diskCounterWidth)
+	writes := delta(initial.WriteCount, final.WriteCount, diskCounterWidth)
+	mergedReads := delta(initial.MergedReadCount, final.MergedReadCount, diskCounterWidth)
+	mergedWrites := delta(initial.MergedWriteCount, final.MergedWriteCount, diskCounterWidth)
+	// Bytes are counted in 512-byte sectors
+	readBytes := delta(initial.ReadBytes, final.ReadBytes, diskCounterWidth*512)
+	writeBytes := delta(initial.WriteBytes, final.WriteBytes, diskCounterWidth*512)
+	readTime := delta(initial.ReadTime, final.ReadTime, diskCounterWidth)
+	writeTime := delta(initial.WriteTime, final.WriteTime, diskCounterWidth)
+	ioTime := delta(initial.IoTime, final.IoTime, diskCounterWidth)
+	weightedIO := delta(initial.WeightedIO, final.WeightedIO, diskCounterWidth)
+	if !valid {
+		return data, false
+	}
+
+	// A device cannot be busy for longer than the interval, so a larger
+	// busy time means the counters restarted from zero rather than wrapped
+	if ioTime > seconds*1000+1000 {
+		return data, false
+	}
+
+	data.ReadSpeed = readBytes / seconds
+	data.WriteSpeed = writeBytes / seconds
+	data.ReadIOPS = reads / seconds
+	data.WriteIOPS = writes / seconds
+	data.ReadMergedIOPS = mergedReads / seconds
+	data.WriteMergedIOPS = mergedWrites / seconds
+
+	if reads > 0 {
+		data.ReadAwait = readTime / reads
+	}
+	if writes > 0 {
+		data.WriteAwait = writeTime / writes
+	}
+
+	// IoTime counts the milliseconds with at least one request in flight,
+	// WeightedIO multiplies them by the number of requests in flight
+	data.Utilization = math.Min(ioTime/(seconds*1000)*100, 100)
+	data.QueueSize = weightedIO / (seconds * 1000)
+
+	return data, true
+}
+
 // FormatPartitionData formats and displays partition data in Prometheus-compatible format
 func FormatPartitionData(partitions *[]PartitionData) string {
 	var formattedData string
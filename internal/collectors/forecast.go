@@ -0,0 +1,93 @@
+package collectors
+
+import (
+	"sync"
+	"sys-monitor-report/internal/report"
+	"time"
+)
+
+const (
+	// diskForecastWindow is how far back used space is fitted
+	diskForecastWindow = 6 * time.Hour
+	// diskForecastMinSpan is the shortest history a forecast is made from, so
+	// a single large write right after startup does not predict a full disk
+	diskForecastMinSpan = 15 * time.Minute
+)
+
+type usageSample struct {
+	time time.Time
+	used float64 // Bytes
+}
+
+var (
+	diskForecastMu sync.Mutex
+	diskUsage      = make(map[string][]usageSample) // Samples in the window by device
+)
+
+// forecastDiskFull fits a least squares line through each device's used
+// space over the forecast window and sets the growth rate and the time left
+// until the free space runs out. Devices that are not filling up have no
+// partition_full_seconds series.
+func forecastDiskFull(partitions []PartitionData, now time.Time) {
+	diskForecastMu.Lock()
+	defer diskForecastMu.Unlock()
+
+	present := make(map[string]bool)
+	for i := range partitions {
+		part := &partitions[i]
+		present[part.Device] = true
+
+		samples := append(diskUsage[part.Device], usageSample{time: now, used: float64(part.Used)})
+		for len(samples) > 0 && now.Sub(samples[0].time) > diskForecastWindow {
+			samples = samples[1:]
+		}
+		diskUsage[part.Device] = samples
+
+		if len(samples) < 3 || now.Sub(samples[0].time) < diskForecastMinSpan {
+			continue
+		}
+
+		part.GrowthRate = usageSlope(samples)
+		report.PartitionGrowth.WithLabelValues(part.Device).Set(part.GrowthRate)
+
+		if part.GrowthRate > 0 {
+			part.FullIn = time.Duration(float64(part.Free) / part.GrowthRate * float64(time.Second))
+			report.PartitionFullSeconds.WithLabelValues(part.Device).Set(part.FullIn.Seconds())
+		} else {
+			report.PartitionFullSeconds.DeleteLabelValues(part.Device)
+		}
+	}
+
+	for device := range diskUsage {
+		if !present[device] {
+			delete(diskUsage, device)
+			report.PartitionGrowth.DeleteLabelValues(device)
+			report.PartitionFullSeconds.DeleteLabelValues(device)
+		}
+	}
+}
+
+// usageSlope returns the growth of used space in bytes per second
+func usageSlope(samples []usageSample) float64 {
+	// Times are taken relative to the first sample to keep the sums small
+	start := samples[0].time
+	n := float64(len(samples))
+
+	var sumX, sumY float64
+	for _, s := range samples {
+		sumX += s.time.Sub(start).Seconds()
+		sumY += s.used
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var covariance, variance float64
+	for _, s := range samples {
+		dx := s.time.Sub(start).Seconds() - meanX
+		covariance += dx * (s.used - meanY)
+		variance += dx * dx
+	}
+	if variance == 0 {
+		return 0
+	}
+	return covariance / variance
+}
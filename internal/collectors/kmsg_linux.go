@@ -0,0 +1,70 @@
+//go:build linux
+
+package collectors
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// oomKillPattern matches e.g. "Out of memory: Killed process 1234 (stress)
+// total-vm:1048576kB, anon-rss:1000000kB, ..." and the memory cgroup variant
+var oomKillPattern = regexp.MustCompile(`Killed process (\d+) \(([^)]*)\)(?:.*anon-rss:(\d+)kB)?`)
+
+var (
+	kmsgOnce sync.Once
+	kmsgFD   = -1
+)
+
+// oomVictims returns the processes killed by the OOM killer since the
+// previous call, e.g. "process 1234 (stress) using 976.6 MB". /dev/kmsg is
+// opened on the first call and read from its end, so older kills are ignored.
+func oomVictims() []string {
+	kmsgOnce.Do(func() {
+		fd, err := syscall.Open("/dev/kmsg", syscall.O_RDONLY|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+		if err != nil {
+			fmt.Printf("Error opening kernel log, OOM kills are reported without the victim: %v\n", err)
+			return
+		}
+		if _, err := syscall.Seek(fd, 0, io.SeekEnd); err != nil {
+			syscall.Close(fd)
+			fmt.Printf("Error opening kernel log, OOM kills are reported without the victim: %v\n", err)
+			return
+		}
+		kmsgFD = fd
+	})
+	if kmsgFD < 0 {
+		return nil
+	}
+
+	var victims []string
+	buf := make([]byte, 8192)
+	for {
+		// Every read returns one record: "priority,sequence,timestamp,flags;message"
+		n, err := syscall.Read(kmsgFD, buf)
+		if err == syscall.EPIPE {
+			// Records were overwritten before they were read
+			continue
+		}
+		if err != nil || n <= 0 {
+			return victims
+		}
+
+		_, message, _ := strings.Cut(string(buf[:n]), ";")
+		match := oomKillPattern.FindStringSubmatch(message)
+		if match == nil {
+			continue
+		}
+
+		victim := fmt.Sprintf("process %s (%s)", match[1], match[2])
+		if kb, err := strconv.ParseUint(match[3], 10, 64); err == nil {
+			victim += fmt.Sprintf(" using %.1f MB", float64(kb)/1024)
+		}
+		victims = append(victims, victim)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package collectors
+
+// oomVictims is only implemented on Linux
+func oomVictims() []string {
+	return nil
+}
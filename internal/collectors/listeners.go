@@ -0,0 +1,364 @@
+package collectors
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sys-monitor-report/internal/events"
+	"sys-monitor-report/internal/report"
+	"sys-monitor-report/internal/utils"
+)
+
+// Listener is a socket accepting TCP connections or UDP datagrams
+type Listener struct {
+	Protocol string `json:"protocol"` // tcp, tcp6, udp or udp6
+	Address  string `json:"address"`
+	Port     int    `json:"port"`
+	PID      int    `json:"pid,omitempty"` // 0 if the owner is not visible, e.g. without root
+	Process  string `json:"process,omitempty"`
+
+	inode uint64
+}
+
+// key identifies a listener across cycles, whichever process owns it
+func (l Listener) key() string {
+	return l.Protocol + " " + net.JoinHostPort(l.Address, strconv.Itoa(l.Port))
+}
+
+func (l Listener) String() string {
+	if l.Process == "" {
+		return l.key()
+	}
+	return fmt.Sprintf("%s (%s, pid %d)", l.key(), l.Process, l.PID)
+}
+
+// listenerPattern is a parsed entry of listeners.expected
+type listenerPattern struct {
+	text     string
+	protocol string
+	address  string // Empty for any address
+	port     int
+}
+
+// matches reports whether the listener is covered by the pattern. tcp and udp
+// cover both IPv4 and IPv6, tcp6 and udp6 only IPv6.
+func (p listenerPattern) matches(l Listener) bool {
+	if p.protocol != l.Protocol && p.protocol != strings.TrimSuffix(l.Protocol, "6") {
+		return false
+	}
+	return p.port == l.Port && (p.address == "" || p.address == l.Address)
+}
+
+var (
+	listenersMu      sync.Mutex
+	lastListeners    []Listener          // Latest inventory, served as JSON
+	knownListeners   map[string]Listener // By key, nil before the first reading
+	listenerSeries   = make(map[string][]string)
+	expectedPatterns []listenerPattern
+	missingExpected  = make(map[string]bool) // By pattern text
+)
+
+// SetExpectedListeners parses the listeners expected to be up, e.g. tcp:22,
+// udp:53 or tcp:127.0.0.1:5432
+func SetExpectedListeners(cfg utils.ListenersConfig) error {
+	var patterns []listenerPattern
+	for _, text := range cfg.Expected {
+		protocol, rest, ok := strings.Cut(text, ":")
+		switch {
+		case !ok:
+			return fmt.Errorf("invalid listeners.expected entry %q: want protocol:port or protocol:address:port", text)
+		case protocol != "tcp" && protocol != "tcp6" && protocol != "udp" && protocol != "udp6":
+			return fmt.Errorf("invalid listeners.expected entry %q: unknown protocol %s", text, protocol)
+		}
+
+		pattern := listenerPattern{text: text, protocol: protocol}
+		portText := rest
+		if host, port, err := net.SplitHostPort(rest); err == nil {
+			pattern.address = host
+			portText = port
+		}
+		port, err := strconv.Atoi(portText)
+		if err != nil || port <= 0 || port > 65535 {
+			return fmt.Errorf("invalid listeners.expected entry %q: invalid port %s", text, portText)
+		}
+		pattern.port = port
+
+		patterns = append(patterns, pattern)
+	}
+
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	expectedPatterns = patterns
+	return nil
+}
+
+// GetListeners lists the listening TCP sockets and bound, unconnected UDP
+// sockets with their owning processes. From the second call on, an event is
+// published when a listener appears or an expected one disappears: those in
+// listeners.expected if set, otherwise any listener seen before. UDP sockets
+// on ephemeral ports are usually clients and do not raise events.
+func GetListeners() ([]Listener, error) {
+	var listeners []Listener
+	inodes := make(map[uint64]bool)
+
+	for _, protocol := range socketProtocols {
+		entries, err := readSocketTable(protocol)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		listening := "listen"
+		if strings.HasPrefix(protocol, "udp") {
+			listening = "close"
+		}
+
+		for _, entry := range entries {
+			if entry.state != listening {
+				continue
+			}
+			address, port, err := parseSocketAddress(entry.local)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing %s socket address: %v", protocol, err)
+			}
+			listeners = append(listeners, Listener{
+				Protocol: protocol,
+				Address:  address,
+				Port:     port,
+				inode:    entry.inode,
+			})
+			inodes[entry.inode] = true
+		}
+	}
+
+	owners := socketOwners(inodes)
+	for i := range listeners {
+		if owner, ok := owners[listeners[i].inode]; ok {
+			listeners[i].PID = owner.pid
+			listeners[i].Process = owner.name
+		}
+	}
+
+	sort.Slice(listeners, func(i, j int) bool {
+		a, b := listeners[i], listeners[j]
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		return a.Address < b.Address
+	})
+
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+
+	updateListenerMetrics(listeners)
+	publishListenerChanges(listeners)
+	lastListeners = listeners
+
+	return listeners, nil
+}
+
+// ListenersHandler serves the latest listener inventory as a JSON array
+func ListenersHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		listenersMu.Lock()
+		listeners := append([]Listener{}, lastListeners...)
+		listenersMu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(listeners); err != nil {
+			fmt.Printf("Error writing listeners: %v\n", err)
+		}
+	})
+}
+
+// updateListenerMetrics sets one listening_port_info series per listener and
+// deletes those of listeners that are gone
+func updateListenerMetrics(listeners []Listener) {
+	current := make(map[string][]string)
+	for _, l := range listeners {
+		labels := []string{l.Protocol, l.Address, strconv.Itoa(l.Port), strconv.Itoa(l.PID), l.Process}
+		current[strings.Join(labels, "\x00")] = labels
+		report.ListeningPort.WithLabelValues(labels...).Set(1)
+	}
+
+	for series, labels := range listenerSeries {
+		if _, exists := current[series]; !exists {
+			report.ListeningPort.DeleteLabelValues(labels...)
+		}
+	}
+	listenerSeries = current
+}
+
+// publishListenerChanges compares the listeners with the previous reading and
+// the expected listeners
+func publishListenerChanges(listeners []Listener) {
+	current := make(map[string]Listener, len(listeners))
+	for _, l := range listeners {
+		current[l.key()] = l
+	}
+
+	expected := func(l Listener) bool {
+		for _, pattern := range expectedPatterns {
+			if pattern.matches(l) {
+				return true
+			}
+		}
+		return false
+	}
+	ephemeral := ephemeralPortRange()
+	client := func(l Listener) bool {
+		return strings.HasPrefix(l.Protocol, "udp") && l.Port >= ephemeral[0] && l.Port <= ephemeral[1]
+	}
+
+	if knownListeners != nil {
+		for key, l := range current {
+			if _, known := knownListeners[key]; !known && !expected(l) && !client(l) {
+				publishListenerEvent(events.SeverityWarning, "New listener "+l.String())
+			}
+		}
+		if len(expectedPatterns) == 0 {
+			for key, l := range knownListeners {
+				if _, exists := current[key]; !exists && !client(l) {
+					publishListenerEvent(events.SeverityWarning, "Listener "+l.String()+" disappeared")
+				}
+			}
+		}
+	}
+	knownListeners = current
+
+	for _, pattern := range expectedPatterns {
+		found := false
+		for _, l := range listeners {
+			if pattern.matches(l) {
+				found = true
+				break
+			}
+		}
+
+		switch {
+		case !found && !missingExpected[pattern.text]:
+			missingExpected[pattern.text] = true
+			publishListenerEvent(events.SeverityWarning, "Expected listener "+pattern.text+" is missing")
+		case found && missingExpected[pattern.text]:
+			delete(missingExpected, pattern.text)
+			publishListenerEvent(events.SeverityInfo, "Expected listener "+pattern.text+" is back")
+		}
+	}
+}
+
+func publishListenerEvent(severity, message string) {
+	fmt.Println(message)
+
+	events.Publish(events.Event{
+		Kind:      events.KindListener,
+		Severity:  severity,
+		Collector: "listeners",
+		Metric:    "listening_port_info",
+		Message:   message,
+	})
+}
+
+// ephemeralPortRange reads the local port range the kernel picks client ports
+// from, falling back to the kernel default
+func ephemeralPortRange() [2]int {
+	fields := strings.Fields(readSysfsString(hostProc("sys", "net", "ipv4", "ip_local_port_range")))
+	if len(fields) == 2 {
+		low, errLow := strconv.Atoi(fields[0])
+		high, errHigh := strconv.Atoi(fields[1])
+		if errLow == nil && errHigh == nil {
+			return [2]int{low, high}
+		}
+	}
+	return [2]int{32768, 60999}
+}
+
+// parseSocketAddress decodes an address of /proc/net/tcp and friends, e.g.
+// 0100007F:0016 is 127.0.0.1 port 22. The address is stored as 32-bit words
+// in host byte order, the port in network byte order.
+func parseSocketAddress(text string) (string, int, error) {
+	addrHex, portHex, ok := strings.Cut(text, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid address %s", text)
+	}
+	raw, err := hex.DecodeString(addrHex)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return "", 0, fmt.Errorf("invalid address %s", text)
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port %s", text)
+	}
+
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		binary.BigEndian.PutUint32(ip[i:], binary.NativeEndian.Uint32(raw[i:]))
+	}
+
+	return ip.String(), int(port), nil
+}
+
+type socketOwner struct {
+	pid  int
+	name string
+}
+
+// socketOwners maps socket inodes to the processes holding them, found
+// through the socket:[inode] links in /proc/<pid>/fd. Processes of other
+// users are only visible to root.
+func socketOwners(inodes map[uint64]bool) map[uint64]socketOwner {
+	owners := make(map[uint64]socketOwner)
+	if len(inodes) == 0 {
+		return owners
+	}
+
+	procs, err := os.ReadDir(hostProc())
+	if err != nil {
+		return owners
+	}
+
+	for _, proc := range procs {
+		pid, err := strconv.Atoi(proc.Name())
+		if err != nil {
+			continue
+		}
+		fds, err := os.ReadDir(hostProc(proc.Name(), "fd"))
+		if err != nil {
+			continue
+		}
+
+		for _, fd := range fds {
+			link, err := os.Readlink(hostProc(proc.Name(), "fd", fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 64)
+			if err != nil || !inodes[inode] {
+				continue
+			}
+			if _, found := owners[inode]; !found {
+				owners[inode] = socketOwner{pid: pid, name: readSysfsString(hostProc(proc.Name(), "comm"))}
+			}
+		}
+
+		if len(owners) == len(inodes) {
+			break
+		}
+	}
+
+	return owners
+}
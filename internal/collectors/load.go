@@ -0,0 +1,70 @@
+package collectors
+
+import (
+	"fmt"
+	"sys-monitor-report/internal/report"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/load"
+)
+
+// LoadData holds the load averages and run queue
+type LoadData struct {
+	Load1  float64
+	Load5  float64
+	Load15 float64
+
+	// Load averages divided by the number of logical cores, so 1.0 means
+	// every core is busy on average
+	PerCore1  float64
+	PerCore5  float64
+	PerCore15 float64
+
+	Running int // Tasks currently runnable
+	Blocked int // Tasks blocked on I/O
+}
+
+// GetLoadData collects the load averages, normalises them per core and reads
+// the number of running and blocked tasks
+func GetLoadData() (LoadData, error) {
+	var data LoadData
+
+	avg, err := load.Avg()
+	if err != nil {
+		return data, fmt.Errorf("error collecting load averages: %v", err)
+	}
+	data.Load1 = avg.Load1
+	data.Load5 = avg.Load5
+	data.Load15 = avg.Load15
+
+	numCores, err := cpu.Counts(true)
+	if err != nil {
+		return data, fmt.Errorf("error collecting number of CPU cores: %v", err)
+	}
+	if numCores > 0 {
+		data.PerCore1 = avg.Load1 / float64(numCores)
+		data.PerCore5 = avg.Load5 / float64(numCores)
+		data.PerCore15 = avg.Load15 / float64(numCores)
+	}
+
+	misc, err := load.Misc()
+	if err != nil {
+		return data, fmt.Errorf("error collecting task counts: %v", err)
+	}
+	data.Running = misc.ProcsRunning
+	data.Blocked = misc.ProcsBlocked
+
+	// Update Prometheus Metrics
+	report.LoadAverage.WithLabelValues("load1").Set(data.Load1)
+	report.LoadAverage.WithLabelValues("load5").Set(data.Load5)
+	report.LoadAverage.WithLabelValues("load15").Set(data.Load15)
+
+	report.LoadAveragePerCore.WithLabelValues("load1").Set(data.PerCore1)
+	report.LoadAveragePerCore.WithLabelValues("load5").Set(data.PerCore5)
+	report.LoadAveragePerCore.WithLabelValues("load15").Set(data.PerCore15)
+
+	report.RunQueueTasks.WithLabelValues("running").Set(float64(data.Running))
+	report.RunQueueTasks.WithLabelValues("blocked").Set(float64(data.Blocked))
+
+	return data, nil
+}
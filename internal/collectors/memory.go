@@ -11,6 +11,7 @@ type MemoryData struct {
 	VirtualMemory VirtualMemoryData
 	SwapMemory    SwapMemoryData
 	Memory        CombinedMemoryData
+	Details       MemoryDetails
 }
 
 type VirtualMemoryData struct {
@@ -27,6 +28,27 @@ type SwapMemoryData struct {
 	UsedPercent float64
 }
 
+// MemoryDetails breaks RAM usage down further, in bytes. RAM+swap totals
+// hide real pressure, so AvailablePercent is the better signal.
+type MemoryDetails struct {
+	Available         uint64
+	AvailablePercent  float64 // Available share of RAM
+	Buffers           uint64
+	Cached            uint64
+	Shared            uint64
+	SlabReclaimable   uint64
+	SlabUnreclaimable uint64
+	Dirty             uint64
+	Writeback         uint64
+	Mapped            uint64
+	PageTables        uint64
+	CommittedAS       uint64 // Memory promised to processes
+	CommitLimit       uint64
+	HugePagesTotal    uint64
+	HugePagesFree     uint64
+	HugePagesReserved uint64
+}
+
 type CombinedMemoryData struct {
 	Total       uint64
 	Used        uint64
@@ -48,6 +70,28 @@ func GetMemoryData() (MemoryData, error) {
 		UsedPercent: vmStats.UsedPercent,
 	}
 
+	data.Details = MemoryDetails{
+		Available:         vmStats.Available,
+		Buffers:           vmStats.Buffers,
+		Cached:            vmStats.Cached,
+		Shared:            vmStats.Shared,
+		SlabReclaimable:   vmStats.Sreclaimable,
+		SlabUnreclaimable: vmStats.Sunreclaim,
+		Dirty:             vmStats.Dirty,
+		Writeback:         vmStats.WriteBack,
+		Mapped:            vmStats.Mapped,
+		PageTables:        vmStats.PageTables,
+		CommittedAS:       vmStats.CommittedAS,
+		CommitLimit:       vmStats.CommitLimit,
+		// Huge pages are reported as page counts
+		HugePagesTotal:    vmStats.HugePagesTotal * vmStats.HugePageSize,
+		HugePagesFree:     vmStats.HugePagesFree * vmStats.HugePageSize,
+		HugePagesReserved: vmStats.HugePagesRsvd * vmStats.HugePageSize,
+	}
+	if vmStats.Total > 0 {
+		data.Details.AvailablePercent = float64(vmStats.Available) / float64(vmStats.Total) * 100
+	}
+
 	swapStats, err := mem.SwapMemory()
 	if err != nil {
 		return data, fmt.Errorf("error collecting swap memory data: %v", err)
@@ -85,6 +129,27 @@ func GetMemoryData() (MemoryData, error) {
 	report.SwapMemoryUsage.WithLabelValues("used_mb").Set(float64(data.SwapMemory.Used) / 1e6)
 	report.SwapMemoryUsage.WithLabelValues("free_mb").Set(float64(data.SwapMemory.Free) / 1e6)
 
+	report.MemoryAvailablePercent.Set(data.Details.AvailablePercent)
+	for name, value := range map[string]uint64{
+		"available":          data.Details.Available,
+		"buffers":            data.Details.Buffers,
+		"cached":             data.Details.Cached,
+		"shared":             data.Details.Shared,
+		"slab_reclaimable":   data.Details.SlabReclaimable,
+		"slab_unreclaimable": data.Details.SlabUnreclaimable,
+		"dirty":              data.Details.Dirty,
+		"writeback":          data.Details.Writeback,
+		"mapped":             data.Details.Mapped,
+		"page_tables":        data.Details.PageTables,
+		"committed_as":       data.Details.CommittedAS,
+		"commit_limit":       data.Details.CommitLimit,
+		"hugepages_total":    data.Details.HugePagesTotal,
+		"hugepages_free":     data.Details.HugePagesFree,
+		"hugepages_reserved": data.Details.HugePagesReserved,
+	} {
+		report.MemoryBytes.WithLabelValues(name).Set(float64(value))
+	}
+
 	return data, nil
 }
 
@@ -0,0 +1,136 @@
+package collectors
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sys-monitor-report/internal/report"
+)
+
+// pressureResources are the files read from /proc/pressure
+var pressureResources = []string{"cpu", "memory", "io"}
+
+// PressureStall holds one line of a PSI file. "some" counts time in which at
+// least one task was stalled, "full" time in which all tasks were.
+type PressureStall struct {
+	Avg10  float64 // Percent of the last 10 seconds
+	Avg60  float64 // Percent of the last minute
+	Avg300 float64 // Percent of the last 5 minutes
+	Total  uint64  // Total stall time in microseconds
+}
+
+// PressureData holds the some and full stall lines per resource
+type PressureData struct {
+	Some map[string]PressureStall
+	Full map[string]PressureStall
+}
+
+var (
+	pressureMu     sync.Mutex
+	pressureTotals = make(map[string]uint64) // Last total per resource and scope
+)
+
+// GetPressureData reads the Pressure Stall Information of the CPU, memory and
+// IO. Resources the kernel does not report (no CONFIG_PSI) are skipped.
+func GetPressureData() (PressureData, error) {
+	data := PressureData{
+		Some: make(map[string]PressureStall),
+		Full: make(map[string]PressureStall),
+	}
+
+	for _, resource := range pressureResources {
+		file, err := os.Open(hostProc("pressure", resource))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return data, fmt.Errorf("error reading %s pressure: %v", resource, err)
+		}
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			scope, stall, err := parsePressureLine(scanner.Text())
+			if err != nil {
+				file.Close()
+				return data, fmt.Errorf("error parsing %s pressure: %v", resource, err)
+			}
+			switch scope {
+			case "some":
+				data.Some[resource] = stall
+			case "full":
+				data.Full[resource] = stall
+			}
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return data, fmt.Errorf("error reading %s pressure: %v", resource, err)
+		}
+	}
+
+	// Update Prometheus Metrics
+	for scope, stalls := range map[string]map[string]PressureStall{"some": data.Some, "full": data.Full} {
+		for resource, stall := range stalls {
+			report.PressureStall.WithLabelValues(resource, scope, "avg10").Set(stall.Avg10)
+			report.PressureStall.WithLabelValues(resource, scope, "avg60").Set(stall.Avg60)
+			report.PressureStall.WithLabelValues(resource, scope, "avg300").Set(stall.Avg300)
+			addPressureTotal(resource, scope, stall.Total)
+		}
+	}
+
+	return data, nil
+}
+
+// addPressureTotal adds the stall time since the previous reading to the
+// counter. The first reading is added in full, as the kernel counts from boot.
+func addPressureTotal(resource, scope string, total uint64) {
+	pressureMu.Lock()
+	defer pressureMu.Unlock()
+
+	key := resource + "/" + scope
+	last := pressureTotals[key]
+	pressureTotals[key] = total
+	if total < last {
+		return
+	}
+
+	report.PressureStallSeconds.WithLabelValues(resource, scope).Add(float64(total-last) / 1e6)
+}
+
+// parsePressureLine parses e.g. "some avg10=1.24 avg60=1.71 avg300=1.46 total=30094471"
+func parsePressureLine(line string) (string, PressureStall, error) {
+	var stall PressureStall
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", stall, fmt.Errorf("empty line")
+	}
+
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return "", stall, fmt.Errorf("invalid field %q", field)
+		}
+
+		var err error
+		switch key {
+		case "avg10":
+			stall.Avg10, err = strconv.ParseFloat(value, 64)
+		case "avg60":
+			stall.Avg60, err = strconv.ParseFloat(value, 64)
+		case "avg300":
+			stall.Avg300, err = strconv.ParseFloat(value, 64)
+		case "total":
+			stall.Total, err = strconv.ParseUint(value, 10, 64)
+		}
+		if err != nil {
+			return "", stall, fmt.Errorf("invalid field %q: %v", field, err)
+		}
+	}
+
+	return fields[0], stall, nil
+}
@@ -0,0 +1,55 @@
+package collectors
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// hostProc returns a path below /proc, or below $HOST_PROC when the agent
+// runs in a container with the host's /proc mounted elsewhere (the same
+// variable gopsutil honours)
+func hostProc(elem ...string) string {
+	return hostPath("HOST_PROC", "/proc", elem)
+}
+
+// hostSys returns a path below /sys, or below $HOST_SYS
+func hostSys(elem ...string) string {
+	return hostPath("HOST_SYS", "/sys", elem)
+}
+
+func hostPath(env, fallback string, elem []string) string {
+	root := os.Getenv(env)
+	if root == "" {
+		root = fallback
+	}
+	return filepath.Join(append([]string{root}, elem...)...)
+}
+
+// readSysfsUint reads a file holding a single unsigned number
+func readSysfsUint(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// readSysfsInt reads a file holding a single signed number
+func readSysfsInt(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// readSysfsString reads a file holding a single value, returning "" on error
+func readSysfsString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
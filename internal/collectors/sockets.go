@@ -0,0 +1,197 @@
+package collectors
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sys-monitor-report/internal/report"
+	"time"
+)
+
+// socketProtocols are the socket tables read from /proc/net
+var socketProtocols = []string{"tcp", "tcp6", "udp", "udp6"}
+
+// tcpStates names the socket states of include/net/tcp_states.h. UDP sockets
+// are either established (connected) or close.
+var tcpStates = map[uint64]string{
+	0x01: "established",
+	0x02: "syn_sent",
+	0x03: "syn_recv",
+	0x04: "fin_wait1",
+	0x05: "fin_wait2",
+	0x06: "time_wait",
+	0x07: "close",
+	0x08: "close_wait",
+	0x09: "last_ack",
+	0x0A: "listen",
+	0x0B: "closing",
+	0x0C: "new_syn_recv",
+}
+
+// socketGrowthWindow is the period over which the growth of TCP states is
+// measured
+const socketGrowthWindow = 10 * time.Minute
+
+// socketEntry is one line of a /proc/net socket table
+type socketEntry struct {
+	local   string // Hex address and port, e.g. 0100007F:0016
+	remote  string
+	state   string
+	txQueue uint64 // Bytes in the send queue
+	rxQueue uint64 // Bytes in the receive queue, or the accept backlog of a listener
+	inode   uint64
+}
+
+// SocketData holds socket counts and queue totals per protocol
+type SocketData struct {
+	Counts  map[string]map[string]int // By protocol and state
+	RxQueue map[string]uint64         // Bytes by protocol
+	TxQueue map[string]uint64         // Bytes by protocol
+
+	// Change in IPv4 and IPv6 TCP sockets per state over the growth window,
+	// empty until the agent has run for that long
+	TCPGrowth map[string]int
+}
+
+type socketCountSample struct {
+	time   time.Time
+	counts map[string]int
+}
+
+var (
+	socketMu      sync.Mutex
+	socketSamples []socketCountSample // TCP counts by state over the growth window
+)
+
+// GetSocketData counts sockets by protocol and state and sums their queues.
+// Protocols the kernel does not report, e.g. tcp6 without IPv6, are skipped.
+func GetSocketData() (SocketData, error) {
+	data := SocketData{
+		Counts:    make(map[string]map[string]int),
+		RxQueue:   make(map[string]uint64),
+		TxQueue:   make(map[string]uint64),
+		TCPGrowth: make(map[string]int),
+	}
+	tcpCounts := make(map[string]int)
+
+	for _, protocol := range socketProtocols {
+		entries, err := readSocketTable(protocol)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return data, err
+		}
+
+		counts := make(map[string]int)
+		if strings.HasPrefix(protocol, "tcp") {
+			// Every TCP state is exported, so the series exist before the
+			// first socket enters a state
+			for _, state := range tcpStates {
+				counts[state] = 0
+			}
+		} else {
+			counts["established"] = 0
+			counts["close"] = 0
+		}
+
+		for _, entry := range entries {
+			counts[entry.state]++
+			data.RxQueue[protocol] += entry.rxQueue
+			data.TxQueue[protocol] += entry.txQueue
+		}
+		data.Counts[protocol] = counts
+
+		// Update Prometheus Metrics
+		for state, count := range counts {
+			report.SocketCount.WithLabelValues(protocol, state).Set(float64(count))
+			if strings.HasPrefix(protocol, "tcp") {
+				tcpCounts[state] += count
+			}
+		}
+		report.SocketQueue.WithLabelValues(protocol, "rx").Set(float64(data.RxQueue[protocol]))
+		report.SocketQueue.WithLabelValues(protocol, "tx").Set(float64(data.TxQueue[protocol]))
+	}
+
+	socketMu.Lock()
+	defer socketMu.Unlock()
+
+	now := time.Now()
+	socketSamples = append(socketSamples, socketCountSample{time: now, counts: tcpCounts})
+	// Keep the newest sample that is at least a window old as the base
+	for len(socketSamples) > 1 && now.Sub(socketSamples[1].time) >= socketGrowthWindow {
+		socketSamples = socketSamples[1:]
+	}
+
+	if base := socketSamples[0]; now.Sub(base.time) >= socketGrowthWindow {
+		for state, count := range tcpCounts {
+			data.TCPGrowth[state] = count - base.counts[state]
+			report.TCPSocketGrowth.WithLabelValues(state).Set(float64(data.TCPGrowth[state]))
+		}
+	}
+
+	return data, nil
+}
+
+// readSocketTable parses /proc/net/<protocol>, e.g.
+// sl local_address rem_address st tx_queue:rx_queue tr tm->when retrnsmt uid timeout inode
+// 0: 0100007F:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000 0 0 12345 ...
+func readSocketTable(protocol string) ([]socketEntry, error) {
+	file, err := os.Open(hostProc("net", protocol))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []socketEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // Header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+
+		state, err := strconv.ParseUint(fields[3], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s socket state: %v", protocol, err)
+		}
+		tx, rx, _ := strings.Cut(fields[4], ":")
+		txQueue, err := strconv.ParseUint(tx, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s socket queue: %v", protocol, err)
+		}
+		rxQueue, err := strconv.ParseUint(rx, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s socket queue: %v", protocol, err)
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s socket inode: %v", protocol, err)
+		}
+
+		name, ok := tcpStates[state]
+		if !ok {
+			name = "unknown"
+		}
+
+		entries = append(entries, socketEntry{
+			local:   fields[1],
+			remote:  fields[2],
+			state:   name,
+			txQueue: txQueue,
+			rxQueue: rxQueue,
+			inode:   inode,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s sockets: %v", protocol, err)
+	}
+
+	return entries, nil
+}
@@ -3,30 +3,123 @@ package collectors
 import (
 	"fmt"
 	"sync"
+	"sys-monitor-report/internal/events"
 	"sys-monitor-report/internal/utils"
 	"time"
 )
 
+// samplingOnce starts the dynamic samplers on the first collection cycle only,
+// as they keep running in the background
+var samplingOnce sync.Once
+
 // CollectSystemMetrics gathers system metrics and updates Prometheus metrics
 func CollectSystemMetrics(config utils.Config) {
 	var wg sync.WaitGroup
 
-	// Dynamic sampling for CPU and Memory
-	go DynamicSampling(
-		"cpu",
-		float64(config.Thresholds.CPU),
-		time.Second*time.Duration(config.LogInterval),
-		time.Second*time.Duration(config.LogIntervalHighFreq),
-		30*time.Second,
-	)
+	// Dynamic sampling for CPU, Memory, Load and Temperature
+	samplingOnce.Do(func() {
+		go DynamicSampling(
+			"cpu",
+			float64(config.Thresholds.CPU),
+			time.Second*time.Duration(config.LogInterval),
+			time.Second*time.Duration(config.LogIntervalHighFreq),
+			30*time.Second,
+		)
 
-	go DynamicSampling(
-		"memory",
-		float64(config.Thresholds.Memory),
-		time.Second*time.Duration(config.LogInterval),
-		time.Second*time.Duration(config.LogIntervalHighFreq),
-		30*time.Second,
-	)
+		go DynamicSampling(
+			"memory",
+			float64(config.Thresholds.Memory),
+			time.Second*time.Duration(config.LogInterval),
+			time.Second*time.Duration(config.LogIntervalHighFreq),
+			30*time.Second,
+		)
+
+		if config.Thresholds.Load > 0 {
+			go DynamicSampling(
+				"load",
+				config.Thresholds.Load,
+				time.Second*time.Duration(config.LogInterval),
+				time.Second*time.Duration(config.LogIntervalHighFreq),
+				30*time.Second,
+			)
+		}
+
+		if config.Thresholds.Temperature > 0 {
+			go DynamicSampling(
+				"temperature",
+				float64(config.Thresholds.Temperature),
+				time.Second*time.Duration(config.LogInterval),
+				time.Second*time.Duration(config.LogIntervalHighFreq),
+				30*time.Second,
+			)
+		}
+	})
+
+	// Collect CPU Times by Mode
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := GetCPUTimes()
+		if err != nil {
+			fmt.Printf("Error collecting CPU times: %v\n", err)
+			events.PublishError("cpu", err)
+		}
+	}()
+
+	// Collect CPU Frequency and Throttling
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := GetCPUFrequencyData()
+		if err != nil {
+			fmt.Printf("Error collecting CPU frequency data: %v\n", err)
+			events.PublishError("cpufreq", err)
+		}
+	}()
+
+	// Collect Load Averages and Run Queue
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := GetLoadData()
+		if err != nil {
+			fmt.Printf("Error collecting load data: %v\n", err)
+			events.PublishError("load", err)
+		}
+	}()
+
+	// Collect Pressure Stall Information
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := GetPressureData()
+		if err != nil {
+			fmt.Printf("Error collecting pressure data: %v\n", err)
+			events.PublishError("pressure", err)
+		}
+	}()
+
+	// Collect Paging Activity and OOM Kills
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := GetVMStatData()
+		if err != nil {
+			fmt.Printf("Error collecting vmstat data: %v\n", err)
+			events.PublishError("vmstat", err)
+		}
+	}()
+
+	// Collect Temperatures
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := GetTemperatureData()
+		if err != nil {
+			fmt.Printf("Error collecting temperatures: %v\n", err)
+			events.PublishError("temperature", err)
+		}
+	}()
 
 	// Collect Partition Data
 	wg.Add(1)
@@ -35,6 +128,7 @@ func CollectSystemMetrics(config utils.Config) {
 		_, err := GetPartitionData()
 		if err != nil {
 			fmt.Printf("Error collecting partition data: %v\n", err)
+			events.PublishError("disk", err)
 			return
 		}
 		// UpdatePartitionMetrics(partitionData) // Updates Prometheus metrics
@@ -44,14 +138,37 @@ func CollectSystemMetrics(config utils.Config) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		_, err := GetDiskIOSpeeds(time.Second * 10)
+		_, err := GetDiskIOSpeeds()
 		if err != nil {
 			fmt.Printf("Error collecting disk I/O speeds: %v\n", err)
+			events.PublishError("diskio", err)
 			return
 		}
 		// UpdateDiskIOMetrics(diskIOData) // Updates Prometheus metrics
 	}()
 
+	// Collect Socket States
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := GetSocketData()
+		if err != nil {
+			fmt.Printf("Error collecting socket data: %v\n", err)
+			events.PublishError("sockets", err)
+		}
+	}()
+
+	// Collect Listening Ports
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := GetListeners()
+		if err != nil {
+			fmt.Printf("Error collecting listeners: %v\n", err)
+			events.PublishError("listeners", err)
+		}
+	}()
+
 	// Collect Top Processes (CPU and Memory)
 	wg.Add(1)
 	go func() {
@@ -59,6 +176,7 @@ func CollectSystemMetrics(config utils.Config) {
 		_, err := GetTopProcesses("cpu", 10)
 		if err != nil {
 			fmt.Printf("Error collecting top CPU processes: %v\n", err)
+			events.PublishError("processes", err)
 			return
 		}
 		// UpdateTopProcessesMetrics(topCPUProcesses, "cpu")
@@ -66,6 +184,7 @@ func CollectSystemMetrics(config utils.Config) {
 		_, err = GetTopProcesses("memory", 10)
 		if err != nil {
 			fmt.Printf("Error collecting top memory processes: %v\n", err)
+			events.PublishError("processes", err)
 			return
 		}
 		// UpdateTopProcessesMetrics(topMemoryProcesses, "memory")
@@ -117,7 +236,7 @@ func CollectSystemMetrics(config utils.Config) {
 
 	go func() {
 		defer wg.Done()
-		ioSpeed, err := GetDiskIOSpeeds(time.Second * 10)
+		ioSpeed, err := GetDiskIOSpeeds()
 		output := ""
 		if err != nil {
 			output = fmt.Sprintf("error collecting disk IO speeds: %v\n", err)
@@ -161,6 +280,16 @@ func CollectSystemMetrics(config utils.Config) {
 	}
 } */
 
+// spikeMetrics names the series each sampled metric is read from and the
+// unit of its values
+var spikeMetrics = map[string]struct{ series, unit string }{
+	"cpu":    {"cpu_overall_usage", "%"},
+	"memory": {`overall_memory_usage{type="used_percent"}`, "%"},
+	"load":   {`load_average_per_core{type="load1"}`, ""},
+	// The hottest sensor
+	"temperature": {"temperature_celsius", "°C"},
+}
+
 // DynamicSampling monitors metrics dynamically
 func DynamicSampling(
 	metric string,
@@ -171,18 +300,30 @@ func DynamicSampling(
 	currentInterval := normalInterval
 	highFreqTimer := time.NewTimer(0)
 	highFreqActive := false
+	aboveThreshold := false
+
+	// CPU usage is measured against the previous reading, so the first one
+	// is taken now rather than on the first tick
+	if metric == "cpu" {
+		if _, err := GetCPUData(); err != nil {
+			fmt.Printf("Error collecting CPU data: %v\n", err)
+		}
+	}
 
 	for {
 		select {
 		case <-time.After(currentInterval):
 			var spikeDetected bool
+			var value float64
 			switch metric {
 			case "cpu":
 				cpuData, err := GetCPUData()
 				if err != nil {
 					fmt.Printf("Error collecting CPU data: %v\n", err)
+					events.PublishError("cpu", err)
 					continue
 				}
+				value = cpuData.TotalUsage
 
 				// fmt.Printf("=== CPU Metrics ===\n")
 				// DisplayCPUData(&cpuData)
@@ -194,8 +335,10 @@ func DynamicSampling(
 				memoryData, err := GetMemoryData()
 				if err != nil {
 					fmt.Printf("Error collecting memory data: %v\n", err)
+					events.PublishError("memory", err)
 					continue
 				}
+				value = memoryData.Memory.UsedPercent
 
 				// fmt.Printf("=== Memory Metrics ===\n")
 				// FormatMemoryData(&memoryData)
@@ -203,7 +346,59 @@ func DynamicSampling(
 					fmt.Printf("Memory Spike Detected: %.2f%%\n", memoryData.Memory.UsedPercent)
 					spikeDetected = true
 				}
+			case "load":
+				loadData, err := GetLoadData()
+				if err != nil {
+					fmt.Printf("Error collecting load data: %v\n", err)
+					events.PublishError("load", err)
+					continue
+				}
+				value = loadData.PerCore1
+
+				// The run queue is saturated once there are more runnable
+				// tasks than cores on average
+				if loadData.PerCore1 > threshold {
+					fmt.Printf("Load Spike Detected: %.2f per core, %d running, %d blocked\n",
+						loadData.PerCore1, loadData.Running, loadData.Blocked)
+					spikeDetected = true
+				}
+			case "temperature":
+				sensors, err := GetTemperatureData()
+				if err != nil {
+					fmt.Printf("Error collecting temperatures: %v\n", err)
+					events.PublishError("temperature", err)
+					continue
+				}
+
+				var hottest TemperatureSensor
+				for _, sensor := range sensors {
+					if sensor.Celsius > hottest.Celsius {
+						hottest = sensor
+					}
+				}
+				value = hottest.Celsius
+
+				if hottest.Celsius > threshold {
+					fmt.Printf("Temperature Spike Detected: %.1f°C (%s %s)\n",
+						hottest.Celsius, hottest.Chip, hottest.Sensor)
+					spikeDetected = true
+				}
+			}
+
+			// Only the first sample of a spike is published
+			if spikeDetected && !aboveThreshold {
+				events.Publish(events.Event{
+					Kind:      events.KindSpike,
+					Severity:  events.SeverityWarning,
+					Collector: metric,
+					Metric:    spikeMetrics[metric].series,
+					Value:     value,
+					Threshold: threshold,
+					Message: fmt.Sprintf("%s spike detected: %.2f%s (threshold %g%s)",
+						metric, value, spikeMetrics[metric].unit, threshold, spikeMetrics[metric].unit),
+				})
 			}
+			aboveThreshold = spikeDetected
 
 			// Adjust sampling rate if a spike is detected
 			if spikeDetected && !highFreqActive {
@@ -0,0 +1,191 @@
+package collectors
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sys-monitor-report/internal/report"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TemperatureSensor holds one temperature reading and its trip points.
+// Trip points are 0 when the sensor does not report them.
+type TemperatureSensor struct {
+	Chip     string // hwmon driver (coretemp, nvme, ...) or thermal zone type
+	Sensor   string // Sensor label, e.g. "Package id 0" or "Core 1"
+	Celsius  float64
+	High     float64
+	Critical float64
+}
+
+var (
+	temperatureMu sync.Mutex
+	lastSensors   = make(map[[2]string]TemperatureSensor) // By chip and sensor
+)
+
+// GetTemperatureData reads every hwmon temperature input and thermal zone
+// below /sys/class (or $HOST_SYS/class). Sensors that cannot be read, e.g.
+// of a sleeping device, are skipped and their metrics deleted.
+func GetTemperatureData() ([]TemperatureSensor, error) {
+	hwmon, err := hwmonTemperatures()
+	if err != nil {
+		return nil, err
+	}
+	zones, err := thermalZoneTemperatures()
+	if err != nil {
+		return nil, err
+	}
+	sensors := append(hwmon, zones...)
+
+	temperatureMu.Lock()
+	defer temperatureMu.Unlock()
+
+	// Update Prometheus Metrics
+	current := make(map[[2]string]TemperatureSensor, len(sensors))
+	for _, sensor := range sensors {
+		current[[2]string{sensor.Chip, sensor.Sensor}] = sensor
+		report.Temperature.WithLabelValues(sensor.Chip, sensor.Sensor).Set(sensor.Celsius)
+		if sensor.High > 0 {
+			report.TemperatureThreshold.WithLabelValues(sensor.Chip, sensor.Sensor, "high").Set(sensor.High)
+		}
+		if sensor.Critical > 0 {
+			report.TemperatureThreshold.WithLabelValues(sensor.Chip, sensor.Sensor, "critical").Set(sensor.Critical)
+		}
+	}
+
+	for key, last := range lastSensors {
+		sensor, exists := current[key]
+		if !exists {
+			report.Temperature.DeleteLabelValues(key[0], key[1])
+			report.TemperatureThreshold.DeletePartialMatch(prometheus.Labels{"chip": key[0], "sensor": key[1]})
+			continue
+		}
+		if last.High > 0 && sensor.High <= 0 {
+			report.TemperatureThreshold.DeleteLabelValues(key[0], key[1], "high")
+		}
+		if last.Critical > 0 && sensor.Critical <= 0 {
+			report.TemperatureThreshold.DeleteLabelValues(key[0], key[1], "critical")
+		}
+	}
+	lastSensors = current
+
+	return sensors, nil
+}
+
+// hwmonTemperatures reads tempN_input with tempN_label, tempN_max and
+// tempN_crit of every hwmon device. Older drivers keep the files in the
+// device subdirectory.
+func hwmonTemperatures() ([]TemperatureSensor, error) {
+	dirs, err := filepath.Glob(hostSys("class", "hwmon", "hwmon*"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing hwmon devices: %v", err)
+	}
+	sort.Strings(dirs)
+
+	var sensors []TemperatureSensor
+	chips := make(map[string]int) // Devices per driver, e.g. one per NVMe drive
+
+	for _, dir := range dirs {
+		name := readSysfsString(filepath.Join(dir, "name"))
+		if name == "" {
+			name = readSysfsString(filepath.Join(dir, "device", "name"))
+		}
+		if name == "" {
+			name = filepath.Base(dir)
+		}
+
+		chip := name
+		if chips[name]++; chips[name] > 1 {
+			chip = fmt.Sprintf("%s_%d", name, chips[name])
+		}
+
+		inputs, _ := filepath.Glob(filepath.Join(dir, "temp*_input"))
+		deviceInputs, _ := filepath.Glob(filepath.Join(dir, "device", "temp*_input"))
+		inputs = append(inputs, deviceInputs...)
+		sort.Strings(inputs)
+
+		for _, input := range inputs {
+			prefix := strings.TrimSuffix(input, "_input")
+
+			millidegrees, err := readSysfsInt(input)
+			if err != nil {
+				continue
+			}
+
+			sensor := TemperatureSensor{
+				Chip:    chip,
+				Sensor:  readSysfsString(prefix + "_label"),
+				Celsius: float64(millidegrees) / 1000,
+			}
+			if sensor.Sensor == "" {
+				sensor.Sensor = filepath.Base(prefix)
+			}
+			if high, err := readSysfsInt(prefix + "_max"); err == nil {
+				sensor.High = float64(high) / 1000
+			}
+			if critical, err := readSysfsInt(prefix + "_crit"); err == nil {
+				sensor.Critical = float64(critical) / 1000
+			}
+
+			sensors = append(sensors, sensor)
+		}
+	}
+
+	return sensors, nil
+}
+
+// thermalZoneTemperatures reads the temperature and the hot and critical trip
+// points of every thermal zone. Passive trip points count as high if the zone
+// has no hot one.
+func thermalZoneTemperatures() ([]TemperatureSensor, error) {
+	dirs, err := filepath.Glob(hostSys("class", "thermal", "thermal_zone*"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing thermal zones: %v", err)
+	}
+	sort.Strings(dirs)
+
+	var sensors []TemperatureSensor
+	for _, dir := range dirs {
+		millidegrees, err := readSysfsInt(filepath.Join(dir, "temp"))
+		if err != nil {
+			continue
+		}
+
+		sensor := TemperatureSensor{
+			Chip:    readSysfsString(filepath.Join(dir, "type")),
+			Sensor:  filepath.Base(dir),
+			Celsius: float64(millidegrees) / 1000,
+		}
+		if sensor.Chip == "" {
+			sensor.Chip = "thermal_zone"
+		}
+
+		var passive float64
+		trips, _ := filepath.Glob(filepath.Join(dir, "trip_point_*_type"))
+		for _, trip := range trips {
+			temp, err := readSysfsInt(strings.TrimSuffix(trip, "_type") + "_temp")
+			if err != nil || temp <= 0 {
+				continue
+			}
+
+			switch readSysfsString(trip) {
+			case "critical":
+				sensor.Critical = float64(temp) / 1000
+			case "hot":
+				sensor.High = float64(temp) / 1000
+			case "passive":
+				passive = float64(temp) / 1000
+			}
+		}
+		if sensor.High == 0 {
+			sensor.High = passive
+		}
+
+		sensors = append(sensors, sensor)
+	}
+
+	return sensors, nil
+}
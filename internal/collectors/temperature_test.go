@@ -0,0 +1,118 @@
+package collectors
+
+import (
+	"os"
+	"path/filepath"
+	"sys-monitor-report/internal/report"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// writeSysfs creates the files of a fake sysfs tree, relative to root
+func writeSysfs(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGetTemperatureData(t *testing.T) {
+	dir := t.TempDir()
+	writeSysfs(t, dir, map[string]string{
+		"class/hwmon/hwmon0/name":        "coretemp\n",
+		"class/hwmon/hwmon0/temp1_input": "45000\n",
+		"class/hwmon/hwmon0/temp1_label": "Package id 0\n",
+		"class/hwmon/hwmon0/temp1_max":   "80000\n",
+		"class/hwmon/hwmon0/temp1_crit":  "100000\n",
+		"class/hwmon/hwmon0/temp2_input": "41500\n",
+		"class/hwmon/hwmon0/temp2_label": "Core 0\n",
+		// A sleeping device answers reads with an error
+		"class/hwmon/hwmon0/temp3_input": "",
+
+		"class/hwmon/hwmon1/name":        "nvme\n",
+		"class/hwmon/hwmon1/temp1_input": "38850\n",
+		"class/hwmon/hwmon1/temp1_label": "Composite\n",
+		"class/hwmon/hwmon2/name":        "nvme\n",
+		"class/hwmon/hwmon2/temp1_input": "-5000\n",
+
+		"class/thermal/thermal_zone0/type":              "x86_pkg_temp\n",
+		"class/thermal/thermal_zone0/temp":              "52000\n",
+		"class/thermal/thermal_zone0/trip_point_0_type": "passive\n",
+		"class/thermal/thermal_zone0/trip_point_0_temp": "90000\n",
+		"class/thermal/thermal_zone0/trip_point_1_type": "critical\n",
+		"class/thermal/thermal_zone0/trip_point_1_temp": "105000\n",
+		"class/thermal/thermal_zone1/type":              "acpitz\n",
+		"class/thermal/thermal_zone1/temp":              "not a number\n",
+	})
+	t.Setenv("HOST_SYS", dir)
+
+	sensors, err := GetTemperatureData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []TemperatureSensor{
+		{Chip: "coretemp", Sensor: "Package id 0", Celsius: 45, High: 80, Critical: 100},
+		{Chip: "coretemp", Sensor: "Core 0", Celsius: 41.5},
+		{Chip: "nvme", Sensor: "Composite", Celsius: 38.85},
+		{Chip: "nvme_2", Sensor: "temp1", Celsius: -5},
+		{Chip: "x86_pkg_temp", Sensor: "thermal_zone0", Celsius: 52, High: 90, Critical: 105},
+	}
+	if len(sensors) != len(want) {
+		t.Fatalf("got %d sensors, want %d: %+v", len(sensors), len(want), sensors)
+	}
+	for i := range want {
+		if sensors[i] != want[i] {
+			t.Errorf("sensor %d = %+v, want %+v", i, sensors[i], want[i])
+		}
+	}
+}
+
+func TestGetTemperatureDataWithoutSensors(t *testing.T) {
+	t.Setenv("HOST_SYS", t.TempDir())
+
+	sensors, err := GetTemperatureData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sensors) != 0 {
+		t.Errorf("got %+v, want no sensors", sensors)
+	}
+}
+
+func TestGetTemperatureDataDeletesMissingSensors(t *testing.T) {
+	dir := t.TempDir()
+	writeSysfs(t, dir, map[string]string{
+		"class/hwmon/hwmon0/name":        "drivetemp\n",
+		"class/hwmon/hwmon0/temp1_input": "35000\n",
+		"class/hwmon/hwmon0/temp1_crit":  "70000\n",
+		"class/hwmon/hwmon1/name":        "drivetemp\n",
+		"class/hwmon/hwmon1/temp1_input": "36000\n",
+		"class/hwmon/hwmon1/temp1_crit":  "70000\n",
+	})
+	t.Setenv("HOST_SYS", dir)
+
+	if _, err := GetTemperatureData(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "class/hwmon/hwmon1")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetTemperatureData(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := testutil.CollectAndCount(report.Temperature); n != 1 {
+		t.Errorf("got %d temperature_celsius series, want 1", n)
+	}
+	if n := testutil.CollectAndCount(report.TemperatureThreshold); n != 1 {
+		t.Errorf("got %d temperature_threshold_celsius series, want 1", n)
+	}
+}
@@ -0,0 +1,155 @@
+package collectors
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sys-monitor-report/internal/events"
+	"sys-monitor-report/internal/report"
+	"time"
+)
+
+// vmstatCounters are the /proc/vmstat counters exported as paging activity
+var vmstatCounters = []string{
+	"pswpin",         // Pages swapped in
+	"pswpout",        // Pages swapped out
+	"pgfault",        // Page faults
+	"pgmajfault",     // Page faults that needed disk I/O
+	"pgsteal_direct", // Pages reclaimed by allocating tasks
+	"pgsteal_kswapd", // Pages reclaimed by kswapd
+	"pgscan_direct",  // Pages scanned by allocating tasks
+	"pgscan_kswapd",  // Pages scanned by kswapd
+}
+
+// VMStatData holds the paging counters since boot and their rates since the
+// previous reading
+type VMStatData struct {
+	Counters map[string]uint64
+	Rates    map[string]float64 // Per second, empty on the first reading
+	OOMKills uint64
+}
+
+var (
+	vmstatMu       sync.Mutex
+	lastVMStat     map[string]uint64
+	lastVMStatTime time.Time
+	unnamedKills   int // Counted OOM kills whose victim was not logged yet
+)
+
+// GetVMStatData reads paging, swapping and OOM kill counters. Every new OOM
+// kill is published as an event naming the victim, taken from the kernel log.
+func GetVMStatData() (VMStatData, error) {
+	data := VMStatData{
+		Counters: make(map[string]uint64),
+		Rates:    make(map[string]float64),
+	}
+
+	values, err := readVMStat()
+	if err != nil {
+		return data, err
+	}
+	now := time.Now()
+
+	vmstatMu.Lock()
+	defer vmstatMu.Unlock()
+
+	elapsed := now.Sub(lastVMStatTime).Seconds()
+	for _, name := range append(vmstatCounters, "oom_kill") {
+		value, ok := values[name]
+		if !ok {
+			continue
+		}
+		data.Counters[name] = value
+
+		// The kernel counts from boot, so the first reading is added in full
+		last, seen := lastVMStat[name]
+		if value < last {
+			continue
+		}
+		delta := float64(value - last)
+
+		if name == "oom_kill" {
+			report.OOMKills.Add(delta)
+			continue
+		}
+		report.PagingEvents.WithLabelValues(name).Add(delta)
+		if seen && elapsed > 0 {
+			data.Rates[name] = delta / elapsed
+			report.PagingRate.WithLabelValues(name).Set(data.Rates[name])
+		}
+	}
+	data.OOMKills = data.Counters["oom_kill"]
+
+	if lastVMStat == nil {
+		// Opens the kernel log at its current end, before any kill is counted
+		oomVictims()
+	} else {
+		var kills int
+		if data.OOMKills > lastVMStat["oom_kill"] {
+			kills = int(data.OOMKills - lastVMStat["oom_kill"])
+		}
+		publishOOMKills(kills)
+	}
+
+	lastVMStat = values
+	lastVMStatTime = now
+
+	return data, nil
+}
+
+func readVMStat() (map[string]uint64, error) {
+	file, err := os.Open(hostProc("vmstat"))
+	if err != nil {
+		return nil, fmt.Errorf("error reading vmstat: %v", err)
+	}
+	defer file.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+			values[name] = n
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading vmstat: %v", err)
+	}
+
+	return values, nil
+}
+
+// publishOOMKills publishes one event per OOM kill, naming the victims found
+// in the kernel log. The counter goes up just before the kernel logs the
+// victim, so kills still unnamed after another cycle (e.g. without access to
+// /dev/kmsg) are published without the victim.
+func publishOOMKills(newKills int) {
+	victims := oomVictims()
+	for _, victim := range victims {
+		publishOOMKill("OOM killer killed " + victim)
+	}
+
+	previous := max(unnamedKills-len(victims), 0)
+	for i := 0; i < previous; i++ {
+		publishOOMKill("OOM killer killed a process")
+	}
+	unnamedKills = max(newKills-max(len(victims)-unnamedKills, 0), 0)
+}
+
+func publishOOMKill(message string) {
+	fmt.Println(message)
+
+	events.Publish(events.Event{
+		Kind:      events.KindOOMKill,
+		Severity:  events.SeverityError,
+		Collector: "vmstat",
+		Metric:    "oom_kills_total",
+		Message:   message,
+	})
+}
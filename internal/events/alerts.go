@@ -0,0 +1,200 @@
+package events
+
+import (
+	"fmt"
+	"sys-monitor-report/internal/history"
+	"sys-monitor-report/internal/utils"
+)
+
+// AlertRule maps a configured threshold onto the series it applies to
+type AlertRule struct {
+	Metric    string            // cpu, steal, memory, memory_available, disk, disk_full, inodes, memory_pressure, io_pressure, close_wait, time_wait or temperature
+	Name      string            // Metric name of the series
+	Labels    map[string]string // Labels the series must carry
+	Threshold func(utils.ThresholdsConfig) int
+	Below     bool // Breached by values below the threshold instead of above
+}
+
+// AlertRules are shared by live alert events and the alerts in reports
+var AlertRules = []AlertRule{
+	{
+		Metric:    "cpu",
+		Name:      "cpu_overall_usage",
+		Threshold: func(t utils.ThresholdsConfig) int { return t.CPU },
+	},
+	{
+		// Time stolen by other guests on the same host
+		Metric:    "steal",
+		Name:      "cpu_mode_percentage",
+		Labels:    map[string]string{"cpu": "total", "mode": "steal"},
+		Threshold: func(t utils.ThresholdsConfig) int { return t.Steal },
+	},
+	{
+		Metric:    "memory",
+		Name:      "overall_memory_usage",
+		Labels:    map[string]string{"type": "used_percent"},
+		Threshold: func(t utils.ThresholdsConfig) int { return t.Memory },
+	},
+	{
+		Metric:    "memory_available",
+		Name:      "memory_available_percent",
+		Threshold: func(t utils.ThresholdsConfig) int { return t.MemoryAvailable },
+		Below:     true,
+	},
+	{
+		Metric:    "disk",
+		Name:      "partition_space",
+		Labels:    map[string]string{"type": "used_percent"},
+		Threshold: func(t utils.ThresholdsConfig) int { return t.Disk },
+	},
+	{
+		// Catches a disk filling up fast long before it crosses thresholds.disk
+		Metric:    "disk_full",
+		Name:      "partition_full_seconds",
+		Threshold: func(t utils.ThresholdsConfig) int { return t.DiskFullHours * 3600 },
+		Below:     true,
+	},
+	{
+		// Running out of inodes fills a disk just as well
+		Metric:    "inodes",
+		Name:      "partition_inodes",
+		Labels:    map[string]string{"type": "used_percent"},
+		Threshold: func(t utils.ThresholdsConfig) int { return t.Disk },
+	},
+	{
+		// avg60 rather than avg10, so only sustained pressure alerts
+		Metric:    "memory_pressure",
+		Name:      "pressure_stall_percent",
+		Labels:    map[string]string{"resource": "memory", "scope": "some", "type": "avg60"},
+		Threshold: func(t utils.ThresholdsConfig) int { return t.MemoryPressure },
+	},
+	{
+		Metric:    "io_pressure",
+		Name:      "pressure_stall_percent",
+		Labels:    map[string]string{"resource": "io", "scope": "some", "type": "avg60"},
+		Threshold: func(t utils.ThresholdsConfig) int { return t.IOPressure },
+	},
+	{
+		// Sockets piling up in CLOSE_WAIT are never closed by their owner
+		Metric:    "close_wait",
+		Name:      "tcp_socket_growth",
+		Labels:    map[string]string{"state": "close_wait"},
+		Threshold: func(t utils.ThresholdsConfig) int { return t.CloseWaitGrowth },
+	},
+	{
+		// A fast growing TIME_WAIT count points at connections not being reused
+		Metric:    "time_wait",
+		Name:      "tcp_socket_growth",
+		Labels:    map[string]string{"state": "time_wait"},
+		Threshold: func(t utils.ThresholdsConfig) int { return t.TimeWaitGrowth },
+	},
+	{
+		// Every sensor alerts on its own
+		Metric:    "temperature",
+		Name:      "temperature_celsius",
+		Threshold: func(t utils.ThresholdsConfig) int { return t.Temperature },
+	},
+}
+
+// Matches reports whether a series falls under the rule
+func (r AlertRule) Matches(name string, labels map[string]string) bool {
+	if name != r.Name {
+		return false
+	}
+	for label, value := range r.Labels {
+		if labels[label] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Breached reports whether a value is on the wrong side of the threshold
+func (r AlertRule) Breached(value, threshold float64) bool {
+	if r.Below {
+		return value < threshold
+	}
+	return value > threshold
+}
+
+// AlertEvaluator publishes an event whenever a series breaches its threshold
+// or comes back within it
+type AlertEvaluator struct {
+	thresholds utils.ThresholdsConfig
+	firing     map[string]string // Rule metric by key of the series currently past their threshold
+}
+
+func NewAlertEvaluator(thresholds utils.ThresholdsConfig) *AlertEvaluator {
+	return &AlertEvaluator{
+		thresholds: thresholds,
+		firing:     make(map[string]string),
+	}
+}
+
+// Evaluate compares every matching sample of the snapshot with its threshold.
+// Alerts on series missing from the snapshot, e.g. a partition that stopped
+// filling up or a removed device, are resolved.
+func (a *AlertEvaluator) Evaluate(snap history.Snapshot) {
+	for _, rule := range AlertRules {
+		threshold := float64(rule.Threshold(a.thresholds))
+		if threshold <= 0 {
+			continue
+		}
+
+		seen := make(map[string]bool)
+		for _, sample := range snap.Samples {
+			if !rule.Matches(sample.Name, sample.Labels) {
+				continue
+			}
+
+			key := sample.SeriesKey()
+			seen[key] = true
+			breached := rule.Breached(sample.Value, threshold)
+			if breached == (a.firing[key] != "") {
+				continue
+			}
+
+			e := Event{
+				Time:      snap.Time,
+				Kind:      KindAlert,
+				Collector: rule.Metric,
+				Metric:    key,
+				Value:     sample.Value,
+				Threshold: threshold,
+			}
+			if breached {
+				side := "above"
+				if rule.Below {
+					side = "below"
+				}
+				a.firing[key] = rule.Metric
+				e.Severity = SeverityWarning
+				e.State = StateFiring
+				e.Message = fmt.Sprintf("%s alert firing: %s at %.2f, %s threshold %.0f", rule.Metric, key, sample.Value, side, threshold)
+			} else {
+				delete(a.firing, key)
+				e.Severity = SeverityInfo
+				e.State = StateResolved
+				e.Message = fmt.Sprintf("%s alert resolved: %s at %.2f, threshold %.0f", rule.Metric, key, sample.Value, threshold)
+			}
+			Publish(e)
+		}
+
+		for key, metric := range a.firing {
+			if metric != rule.Metric || seen[key] {
+				continue
+			}
+			delete(a.firing, key)
+			Publish(Event{
+				Time:      snap.Time,
+				Kind:      KindAlert,
+				Severity:  SeverityInfo,
+				State:     StateResolved,
+				Collector: rule.Metric,
+				Metric:    key,
+				Threshold: threshold,
+				Message:   fmt.Sprintf("%s alert resolved: %s is no longer reported", rule.Metric, key),
+			})
+		}
+	}
+}
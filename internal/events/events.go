@@ -0,0 +1,79 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Kinds of events
+const (
+	KindSpike          = "spike"           // A sampled value crossed its spike threshold
+	KindAlert          = "alert"           // An alert started firing or was resolved
+	KindCollectorError = "collector_error" // A collector failed to gather its data
+	KindOOMKill        = "oom_kill"        // The kernel killed a process to free memory
+	KindListener       = "listener"        // A listening socket appeared or an expected one disappeared
+)
+
+// Severities, from least to most severe
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Alert states
+const (
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+// Event is something that happened on the host or in the agent, as opposed to
+// the samples taken every cycle
+type Event struct {
+	Time      time.Time
+	Kind      string
+	Severity  string
+	Collector string  // cpu, memory, disk, processes, ...
+	Metric    string  // Metric name or series key, if the event concerns one
+	Value     float64 // Value that triggered the event
+	Threshold float64 // Threshold it was compared against
+	State     string  // firing or resolved, for alerts
+	Message   string
+}
+
+var (
+	mu       sync.RWMutex
+	handlers []func(Event)
+)
+
+// Subscribe registers a handler for every event published afterwards.
+// Handlers run on the publishing goroutine, so they must not block.
+func Subscribe(handler func(Event)) {
+	mu.Lock()
+	defer mu.Unlock()
+	handlers = append(handlers, handler)
+}
+
+// Publish hands the event to every handler, stamping it with the current
+// time if it has none
+func Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, handler := range handlers {
+		handler(e)
+	}
+}
+
+// PublishError reports a collector failure
+func PublishError(collector string, err error) {
+	Publish(Event{
+		Kind:      KindCollectorError,
+		Severity:  SeverityError,
+		Collector: collector,
+		Message:   err.Error(),
+	})
+}
@@ -0,0 +1,210 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"sys-monitor-report/internal/history"
+	"time"
+)
+
+// Supported export formats
+const (
+	FormatCSV     = "csv"      // Wide: one row per snapshot, one column per series
+	FormatCSVLong = "csv-long" // Long: one row per sample, one column per label
+	FormatJSONL   = "jsonl"    // One JSON object per sample
+)
+
+// ContentType returns the MIME type of an export format
+func ContentType(format string) string {
+	if format == FormatJSONL {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Filter keeps only samples whose metric name matches one of the patterns.
+// Patterns use path.Match syntax, e.g. "cpu_*". No patterns keeps everything.
+func Filter(snapshots []history.Snapshot, patterns []string) ([]history.Snapshot, error) {
+	if len(patterns) == 0 {
+		return snapshots, nil
+	}
+
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid metric pattern %q: %v", pattern, err)
+		}
+	}
+
+	filtered := make([]history.Snapshot, 0, len(snapshots))
+	for _, snap := range snapshots {
+		kept := history.Snapshot{Time: snap.Time}
+		for _, sample := range snap.Samples {
+			for _, pattern := range patterns {
+				if matched, _ := path.Match(pattern, sample.Name); matched {
+					kept.Samples = append(kept.Samples, sample)
+					break
+				}
+			}
+		}
+		if len(kept.Samples) > 0 {
+			filtered = append(filtered, kept)
+		}
+	}
+
+	return filtered, nil
+}
+
+// Write encodes snapshots in the given format
+func Write(w io.Writer, snapshots []history.Snapshot, format string) error {
+	switch format {
+	case FormatCSV, "":
+		return writeWideCSV(w, snapshots)
+	case FormatCSVLong:
+		return writeLongCSV(w, snapshots)
+	case FormatJSONL:
+		return writeJSONL(w, snapshots)
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// writeWideCSV writes a timestamp column followed by one column per series
+func writeWideCSV(w io.Writer, snapshots []history.Snapshot) error {
+	columns := make(map[string]int)
+	var keys []string
+	for _, snap := range snapshots {
+		for _, sample := range snap.Samples {
+			key := sample.SeriesKey()
+			if _, exists := columns[key]; !exists {
+				columns[key] = 0
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		columns[key] = i + 1
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(append([]string{"timestamp"}, keys...)); err != nil {
+		return fmt.Errorf("error writing CSV header: %v", err)
+	}
+
+	row := make([]string, len(keys)+1)
+	for _, snap := range snapshots {
+		for i := range row {
+			row[i] = ""
+		}
+		row[0] = snap.Time.UTC().Format(time.RFC3339)
+		for _, sample := range snap.Samples {
+			row[columns[sample.SeriesKey()]] = formatValue(sample.Value)
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("error writing CSV row: %v", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeLongCSV writes timestamp, metric, one column per label name and value
+func writeLongCSV(w io.Writer, snapshots []history.Snapshot) error {
+	labelSet := make(map[string]bool)
+	for _, snap := range snapshots {
+		for _, sample := range snap.Samples {
+			for name := range sample.Labels {
+				labelSet[name] = true
+			}
+		}
+	}
+
+	labelNames := make([]string, 0, len(labelSet))
+	for name := range labelSet {
+		labelNames = append(labelNames, name)
+	}
+	sort.Strings(labelNames)
+
+	writer := csv.NewWriter(w)
+	header := append([]string{"timestamp", "metric"}, labelNames...)
+	if err := writer.Write(append(header, "value")); err != nil {
+		return fmt.Errorf("error writing CSV header: %v", err)
+	}
+
+	row := make([]string, len(labelNames)+3)
+	for _, snap := range snapshots {
+		timestamp := snap.Time.UTC().Format(time.RFC3339)
+		for _, sample := range snap.Samples {
+			row[0] = timestamp
+			row[1] = sample.Name
+			for i, name := range labelNames {
+				row[i+2] = sample.Labels[name]
+			}
+			row[len(row)-1] = formatValue(sample.Value)
+			if err := writer.Write(row); err != nil {
+				return fmt.Errorf("error writing CSV row: %v", err)
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+type jsonlSample struct {
+	Time   time.Time         `json:"time"`
+	Name   string            `json:"metric"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value"`
+}
+
+// writeJSONL writes one JSON object per sample
+func writeJSONL(w io.Writer, snapshots []history.Snapshot) error {
+	encoder := json.NewEncoder(w)
+	for _, snap := range snapshots {
+		for _, sample := range snap.Samples {
+			err := encoder.Encode(jsonlSample{
+				Time:   snap.Time.UTC(),
+				Name:   sample.Name,
+				Labels: sample.Labels,
+				Value:  sample.Value,
+			})
+			if err != nil {
+				return fmt.Errorf("error writing JSON line: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// ParseTime accepts RFC 3339 timestamps, dates (2006-01-02), Unix seconds
+// and durations relative to now (24h means 24 hours ago)
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			d = -d
+		}
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
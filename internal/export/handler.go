@@ -0,0 +1,140 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sys-monitor-report/internal/history"
+	"time"
+)
+
+// Request selects the samples to export
+type Request struct {
+	From    time.Time
+	To      time.Time
+	Metrics []string // Metric name patterns, empty for all
+	Format  string
+}
+
+// Export queries the store and writes the selected samples
+func Export(w io.Writer, store *history.Store, req Request) error {
+	snapshots, err := store.Query(req.From, req.To)
+	if err != nil {
+		return err
+	}
+
+	snapshots, err = Filter(snapshots, req.Metrics)
+	if err != nil {
+		return err
+	}
+
+	return Write(w, snapshots, req.Format)
+}
+
+// SplitList splits comma-separated values, dropping empty entries
+func SplitList(values ...string) []string {
+	var list []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// Limits of exports served over HTTP, which are built in memory. The export
+// command has no limits.
+const (
+	maxHTTPExportRange     = 7 * 24 * time.Hour
+	maxHTTPExportSnapshots = 20000 // A week at one snapshot every 30 seconds
+)
+
+// Handler serves exports at e.g.
+// /api/v1/export?from=24h&metric=cpu_*&metric=partition_space&format=csv-long
+func Handler(store *history.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		now := time.Now()
+
+		req := Request{
+			From:    now.Add(-24 * time.Hour),
+			To:      now,
+			Metrics: SplitList(query["metric"]...),
+			Format:  query.Get("format"),
+		}
+		if req.Format == "" {
+			req.Format = FormatCSV
+		}
+
+		var err error
+		if value := query.Get("from"); value != "" {
+			if req.From, err = ParseTime(value, now); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if value := query.Get("to"); value != "" {
+			if req.To, err = ParseTime(value, now); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		switch req.Format {
+		case FormatCSV, FormatCSVLong, FormatJSONL:
+		default:
+			http.Error(w, fmt.Sprintf("unsupported export format: %s", req.Format), http.StatusBadRequest)
+			return
+		}
+
+		if req.To.Before(req.From) {
+			http.Error(w, "export end is before its start", http.StatusBadRequest)
+			return
+		}
+		if req.To.Sub(req.From) > maxHTTPExportRange {
+			http.Error(
+				w,
+				fmt.Sprintf("export range is limited to %s, use the export command for longer ranges", maxHTTPExportRange),
+				http.StatusBadRequest,
+			)
+			return
+		}
+
+		snapshots, err := store.QueryLimit(req.From, req.To, maxHTTPExportSnapshots)
+		if errors.Is(err, history.ErrTooManySnapshots) {
+			http.Error(
+				w,
+				fmt.Sprintf("export is limited to %d snapshots, narrow the range or use the export command", maxHTTPExportSnapshots),
+				http.StatusRequestEntityTooLarge,
+			)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		snapshots, err = Filter(snapshots, req.Metrics)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		extension := ".csv"
+		if req.Format == FormatJSONL {
+			extension = ".jsonl"
+		}
+		w.Header().Set("Content-Type", ContentType(req.Format))
+		w.Header().Set(
+			"Content-Disposition",
+			fmt.Sprintf("attachment; filename=\"metrics-%s%s\"", now.Format("20060102-150405"), extension),
+		)
+
+		if err := Write(w, snapshots, req.Format); err != nil {
+			fmt.Printf("Error writing export: %v\n", err)
+		}
+	})
+}
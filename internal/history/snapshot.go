@@ -0,0 +1,93 @@
+package history
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Sample is a single labelled metric value
+type Sample struct {
+	Name   string            `json:"name"`
+	Type   string            `json:"type"` // gauge, counter or untyped
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value"`
+}
+
+// Snapshot holds every sample gathered in one collection cycle
+type Snapshot struct {
+	Time    time.Time `json:"time"`
+	Samples []Sample  `json:"samples"`
+}
+
+// Capture gathers the current value of every metric in the given registry
+func Capture(g prometheus.Gatherer, t time.Time) (Snapshot, error) {
+	snap := Snapshot{Time: t}
+
+	families, err := g.Gather()
+	if err != nil {
+		return snap, fmt.Errorf("error gathering metrics: %v", err)
+	}
+
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			sample := Sample{Name: family.GetName()}
+
+			switch family.GetType() {
+			case dto.MetricType_GAUGE:
+				sample.Type = "gauge"
+				sample.Value = metric.GetGauge().GetValue()
+			case dto.MetricType_COUNTER:
+				sample.Type = "counter"
+				sample.Value = metric.GetCounter().GetValue()
+			case dto.MetricType_UNTYPED:
+				sample.Type = "untyped"
+				sample.Value = metric.GetUntyped().GetValue()
+			default:
+				continue
+			}
+
+			// NaN and Inf cannot be encoded as JSON
+			if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+				continue
+			}
+
+			if len(metric.GetLabel()) > 0 {
+				sample.Labels = make(map[string]string, len(metric.GetLabel()))
+				for _, label := range metric.GetLabel() {
+					sample.Labels[label.GetName()] = label.GetValue()
+				}
+			}
+
+			snap.Samples = append(snap.Samples, sample)
+		}
+	}
+
+	return snap, nil
+}
+
+// SeriesKey identifies a sample by its name and sorted label set,
+// e.g. partition_space{device="/dev/sda1",type="used_gb"}
+func (s Sample) SeriesKey() string {
+	if len(s.Labels) == 0 {
+		return s.Name
+	}
+
+	names := make([]string, 0, len(s.Labels))
+	for name := range s.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, s.Labels[name]))
+	}
+
+	return s.Name + "{" + strings.Join(pairs, ",") + "}"
+}
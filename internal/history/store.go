@@ -0,0 +1,277 @@
+package history
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const dayLayout = "2006-01-02"
+
+// Store keeps collected snapshots on disk as one JSON Lines file per UTC day.
+// Files for past days are gzipped and removed once they fall out of retention.
+type Store struct {
+	dir       string
+	interval  time.Duration
+	retention time.Duration
+
+	readOnly bool
+
+	mu         sync.Mutex
+	lastAppend time.Time
+	currentDay string
+}
+
+// NewStore opens (or creates) a history directory. Snapshots arriving less
+// than interval apart are skipped to keep the files small.
+func NewStore(dir string, interval, retention time.Duration) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating history directory: %v", err)
+	}
+
+	store := &Store{
+		dir:       dir,
+		interval:  interval,
+		retention: retention,
+	}
+
+	if err := store.maintain(time.Now()); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// OpenStoreReadOnly opens a history directory for queries only. Unlike
+// NewStore it neither compresses nor removes files, so it is safe to use
+// while the agent owns the directory.
+func OpenStoreReadOnly(dir string) (*Store, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error opening history directory: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("error opening history directory: %s is not a directory", dir)
+	}
+
+	return &Store{dir: dir, readOnly: true}, nil
+}
+
+// Append writes a snapshot to the file for its day
+func (s *Store) Append(snap Snapshot) error {
+	if s.readOnly {
+		return fmt.Errorf("history store is read-only")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.lastAppend.IsZero() && snap.Time.Sub(s.lastAppend) < s.interval {
+		return nil
+	}
+
+	day := snap.Time.UTC().Format(dayLayout)
+	if day != s.currentDay {
+		if err := s.maintain(snap.Time); err != nil {
+			fmt.Printf("Error maintaining history files: %v\n", err)
+		}
+		s.currentDay = day
+	}
+
+	line, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("error encoding snapshot: %v", err)
+	}
+
+	file, err := os.OpenFile(
+		filepath.Join(s.dir, day+".jsonl"),
+		os.O_CREATE|os.O_APPEND|os.O_WRONLY,
+		0o644,
+	)
+	if err != nil {
+		return fmt.Errorf("error opening history file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing history file: %v", err)
+	}
+
+	s.lastAppend = snap.Time
+	return nil
+}
+
+// ErrTooManySnapshots is returned by QueryLimit when the range holds more
+// snapshots than the limit
+var ErrTooManySnapshots = errors.New("too many snapshots")
+
+// Query returns every stored snapshot taken in [from, to), oldest first
+func (s *Store) Query(from, to time.Time) ([]Snapshot, error) {
+	return s.QueryLimit(from, to, 0)
+}
+
+// QueryLimit is Query returning ErrTooManySnapshots as soon as more than
+// limit snapshots are read, so a large range is not loaded into memory.
+// A limit of 0 means no limit.
+func (s *Store) QueryLimit(from, to time.Time, limit int) ([]Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var snapshots []Snapshot
+	for day := truncateDay(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		name := day.Format(dayLayout)
+
+		for _, path := range []string{
+			filepath.Join(s.dir, name+".jsonl.gz"),
+			filepath.Join(s.dir, name+".jsonl"),
+		} {
+			remaining := -1
+			if limit > 0 {
+				remaining = limit - len(snapshots)
+			}
+			daySnapshots, err := readFile(path, from, to, remaining)
+			if err != nil {
+				return nil, err
+			}
+			snapshots = append(snapshots, daySnapshots...)
+		}
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Time.Before(snapshots[j].Time)
+	})
+
+	return snapshots, nil
+}
+
+// maintain compresses finished days and removes days past retention
+func (s *Store) maintain(now time.Time) error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("error reading history directory: %v", err)
+	}
+
+	today := now.UTC().Format(dayLayout)
+	cutoff := truncateDay(now.Add(-s.retention))
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".jsonl") && !strings.HasSuffix(name, ".jsonl.gz") {
+			continue
+		}
+
+		day, err := time.Parse(dayLayout, strings.SplitN(name, ".", 2)[0])
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(s.dir, name)
+
+		if s.retention > 0 && day.Before(cutoff) {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("error removing expired history file: %v", err)
+			}
+			continue
+		}
+
+		if strings.HasSuffix(name, ".jsonl") && day.Format(dayLayout) != today {
+			if err := compressFile(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// readFile decodes the snapshots in [from, to) from a plain or gzipped file,
+// failing with ErrTooManySnapshots after more than limit snapshots unless
+// limit is negative
+func readFile(path string, from, to time.Time, limit int) ([]Snapshot, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening history file: %v", err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("error opening compressed history file: %v", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	var snapshots []Snapshot
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var snap Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snap); err != nil {
+			// A partially written line after a crash should not hide the rest
+			continue
+		}
+		if snap.Time.Before(from) || !snap.Time.Before(to) {
+			continue
+		}
+		if limit >= 0 && len(snapshots) >= limit {
+			return nil, ErrTooManySnapshots
+		}
+		snapshots = append(snapshots, snap)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading history file %s: %v", path, err)
+	}
+
+	return snapshots, nil
+}
+
+// compressFile gzips a finished day file and removes the original
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening history file: %v", err)
+	}
+	defer src.Close()
+
+	// Appending keeps any earlier data for the same day; gzip readers
+	// decode concatenated members as a single stream
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("error creating compressed history file: %v", err)
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		return fmt.Errorf("error compressing history file: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		return fmt.Errorf("error compressing history file: %v", err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("error compressing history file: %v", err)
+	}
+
+	return os.Remove(path)
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -17,6 +17,97 @@ var (
 		[]string{"core"},
 	)
 
+	CPUFrequency = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cpu_frequency_mhz",
+			Help: "Current, minimum and maximum CPU frequency by core",
+		},
+		[]string{"core", "type"},
+	)
+
+	CPUThrottles = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cpu_throttle_events_total",
+			Help: "Thermal throttling events by core, for the core itself and its package",
+		},
+		[]string{"core", "type"},
+	)
+
+	CPUSeconds = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cpu_seconds_total",
+			Help: "Seconds the CPUs spent in each mode",
+		},
+		[]string{"cpu", "mode"},
+	)
+
+	CPUModePercentage = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cpu_mode_percentage",
+			Help: "Share of CPU time spent in each mode since the previous collection",
+		},
+		[]string{"cpu", "mode"},
+	)
+
+	// Load Average and Run Queue
+	LoadAverage = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "load_average",
+			Help: "System load averages over 1, 5 and 15 minutes",
+		},
+		[]string{"type"},
+	)
+
+	LoadAveragePerCore = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "load_average_per_core",
+			Help: "System load averages divided by the number of logical cores",
+		},
+		[]string{"type"},
+	)
+
+	RunQueueTasks = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "run_queue_tasks",
+			Help: "Number of running and blocked tasks",
+		},
+		[]string{"type"},
+	)
+
+	// Pressure Stall Information
+	PressureStall = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "pressure_stall_percent",
+			Help: "Share of time tasks were stalled on a resource, averaged over 10, 60 and 300 seconds",
+		},
+		[]string{"resource", "scope", "type"},
+	)
+
+	PressureStallSeconds = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pressure_stall_seconds_total",
+			Help: "Total time tasks were stalled on a resource",
+		},
+		[]string{"resource", "scope"},
+	)
+
+	// Temperatures
+	Temperature = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "temperature_celsius",
+			Help: "Temperature of hwmon sensors and thermal zones",
+		},
+		[]string{"chip", "sensor"},
+	)
+
+	TemperatureThreshold = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "temperature_threshold_celsius",
+			Help: "High and critical trip points of hwmon sensors and thermal zones",
+		},
+		[]string{"chip", "sensor", "type"},
+	)
+
 	// Memory Usage
 	OverallMemoryUsage = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -42,6 +133,41 @@ var (
 		[]string{"type"},
 	)
 
+	MemoryBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "memory_bytes",
+			Help: "Detailed RAM usage in bytes",
+		},
+		[]string{"type"},
+	)
+
+	MemoryAvailablePercent = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "memory_available_percent",
+		Help: "Share of RAM available to new allocations without swapping",
+	})
+
+	// Paging and OOM Kills
+	PagingEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "paging_events_total",
+			Help: "Swapping, page fault and page reclaim counters from /proc/vmstat",
+		},
+		[]string{"type"},
+	)
+
+	PagingRate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "paging_events_per_second",
+			Help: "Swapping, page fault and page reclaim rates since the previous collection",
+		},
+		[]string{"type"},
+	)
+
+	OOMKills = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "oom_kills_total",
+		Help: "Processes killed by the OOM killer",
+	})
+
 	// Partition Space Usage
 	PartitionSpace = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -59,6 +185,63 @@ var (
 		[]string{"device", "mount"},
 	)
 
+	PartitionInodes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "partition_inodes",
+			Help: "Partition inode usage statistics",
+		},
+		[]string{"device", "mount", "type"},
+	)
+
+	PartitionGrowth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "partition_growth_bytes_per_second",
+			Help: "Growth of used partition space, fitted over the last 6 hours",
+		},
+		[]string{"device"},
+	)
+
+	PartitionFullSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "partition_full_seconds",
+			Help: "Predicted seconds until the partition is full, only for partitions filling up",
+		},
+		[]string{"device"},
+	)
+
+	// Sockets
+	SocketCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "socket_count",
+			Help: "Sockets by protocol and state, from /proc/net",
+		},
+		[]string{"protocol", "state"},
+	)
+
+	SocketQueue = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "socket_queue_bytes",
+			Help: "Bytes waiting in the receive and send queues of all sockets",
+		},
+		[]string{"protocol", "type"},
+	)
+
+	TCPSocketGrowth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "tcp_socket_growth",
+			Help: "Change in IPv4 and IPv6 TCP sockets per state over the last 10 minutes",
+		},
+		[]string{"state"},
+	)
+
+	ListeningPort = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "listening_port_info",
+			Help: "Listening TCP and bound UDP sockets with their owning process, always 1",
+		},
+		[]string{"protocol", "address", "port", "pid", "process"},
+	)
+
 	// Top Processes
 	TopCPUProcesses = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -107,20 +290,89 @@ var (
 		},
 		[]string{"device"},
 	)
+
+	DiskIOOps = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "disk_io_ops_per_second",
+			Help: "Completed and merged read and write operations per second",
+		},
+		[]string{"device", "type"},
+	)
+
+	DiskIOAwait = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "disk_io_await_milliseconds",
+			Help: "Average time per read and write operation, queueing included",
+		},
+		[]string{"device", "type"},
+	)
+
+	DiskIOUtilization = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "disk_io_utilization_percent",
+			Help: "Share of time the device had I/O in flight",
+		},
+		[]string{"device"},
+	)
+
+	DiskIOQueueSize = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "disk_io_queue_size",
+			Help: "Average number of I/O requests in flight",
+		},
+		[]string{"device"},
+	)
 )
 
+// Registry holds only the agent's own metrics. The default registry served on
+// /metrics also carries the Go runtime and process collectors, which are left
+// out of stored history and pushed outputs.
+var Registry = prometheus.NewRegistry()
+
 func Init() {
-	prometheus.MustRegister(OverallCPUUsage)
-	prometheus.MustRegister(PerCoreCPUUsage)
-	prometheus.MustRegister(OverallMemoryUsage)
-	prometheus.MustRegister(VirtualMemoryUsage)
-	prometheus.MustRegister(SwapMemoryUsage)
-	prometheus.MustRegister(PartitionSpace)
-	prometheus.MustRegister(PartitionMountpoints)
-	prometheus.MustRegister(TopCPUProcesses)
-	prometheus.MustRegister(TopMemoryProcesses)
-	prometheus.MustRegister(ProcessIOReadCount)
-	prometheus.MustRegister(ProcessIOWriteCount)
-	prometheus.MustRegister(DiskIOReadSpeed)
-	prometheus.MustRegister(DiskIOWriteSpeed)
+	for _, c := range []prometheus.Collector{
+		OverallCPUUsage,
+		PerCoreCPUUsage,
+		CPUFrequency,
+		CPUThrottles,
+		CPUSeconds,
+		CPUModePercentage,
+		LoadAverage,
+		LoadAveragePerCore,
+		RunQueueTasks,
+		PressureStall,
+		PressureStallSeconds,
+		Temperature,
+		TemperatureThreshold,
+		OverallMemoryUsage,
+		VirtualMemoryUsage,
+		SwapMemoryUsage,
+		MemoryBytes,
+		MemoryAvailablePercent,
+		PagingEvents,
+		PagingRate,
+		OOMKills,
+		PartitionSpace,
+		PartitionMountpoints,
+		PartitionInodes,
+		PartitionGrowth,
+		PartitionFullSeconds,
+		SocketCount,
+		SocketQueue,
+		TCPSocketGrowth,
+		ListeningPort,
+		TopCPUProcesses,
+		TopMemoryProcesses,
+		ProcessIOReadCount,
+		ProcessIOWriteCount,
+		DiskIOReadSpeed,
+		DiskIOWriteSpeed,
+		DiskIOOps,
+		DiskIOAwait,
+		DiskIOUtilization,
+		DiskIOQueueSize,
+	} {
+		prometheus.MustRegister(c)
+		Registry.MustRegister(c)
+	}
 }
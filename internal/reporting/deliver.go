@@ -0,0 +1,154 @@
+package reporting
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sys-monitor-report/internal/utils"
+	"time"
+)
+
+// fileName builds the name a report is stored or uploaded under,
+// e.g. daily-20261019-0700.txt
+func fileName(name string, scheduled time.Time, rendered Rendered) string {
+	return fmt.Sprintf("%s-%s%s", name, scheduled.Format("20060102-1504"), rendered.Extension)
+}
+
+// DeliverToDirectory writes a report file and removes reports past retention
+func DeliverToDirectory(
+	cfg *utils.DirectoryDelivery,
+	name string,
+	scheduled time.Time,
+	rendered Rendered,
+) (string, error) {
+	if err := os.MkdirAll(cfg.Path, 0o755); err != nil {
+		return "", fmt.Errorf("error creating report directory: %v", err)
+	}
+
+	path := filepath.Join(cfg.Path, fileName(name, scheduled, rendered))
+	if err := os.WriteFile(path, rendered.Body, 0o644); err != nil {
+		return "", fmt.Errorf("error writing report: %v", err)
+	}
+
+	if cfg.RetentionDays > 0 {
+		pruneDirectory(cfg.Path, name, time.Now().AddDate(0, 0, -cfg.RetentionDays))
+	}
+
+	return path, nil
+}
+
+// pruneDirectory removes files of the given report older than cutoff
+func pruneDirectory(dir, name string, cutoff time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		fmt.Printf("Error reading report directory %s: %v\n", dir, err)
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), name+"-") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			fmt.Printf("Error removing expired report %s: %v\n", entry.Name(), err)
+		}
+	}
+}
+
+// DeliverByEmail sends a report as the body of an email
+func DeliverByEmail(
+	cfg *utils.EmailDelivery,
+	name string,
+	report Report,
+	rendered Rendered,
+) error {
+	if len(cfg.To) == 0 {
+		return fmt.Errorf("no email recipients configured")
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = 25
+	}
+	addr := fmt.Sprintf("%s:%d", cfg.Host, port)
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	subject := fmt.Sprintf(
+		"[%s] %s report %s - %s",
+		report.Host, name,
+		report.From.Format("2006-01-02 15:04"), report.To.Format("2006-01-02 15:04"),
+	)
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: %s\r\n", rendered.ContentType)
+	fmt.Fprintf(&msg, "\r\n")
+	msg.Write(bytes.ReplaceAll(rendered.Body, []byte("\n"), []byte("\r\n")))
+
+	if err := smtp.SendMail(addr, auth, cfg.From, cfg.To, msg.Bytes()); err != nil {
+		return fmt.Errorf("error sending report email: %v", err)
+	}
+
+	return nil
+}
+
+var uploadClient = &http.Client{Timeout: 30 * time.Second}
+
+// DeliverByHTTP uploads a report to an HTTP endpoint
+func DeliverByHTTP(
+	cfg *utils.HTTPDelivery,
+	name string,
+	scheduled time.Time,
+	rendered Rendered,
+) error {
+	method := cfg.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	req, err := http.NewRequest(method, cfg.URL, bytes.NewReader(rendered.Body))
+	if err != nil {
+		return fmt.Errorf("error creating report upload request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", rendered.ContentType)
+	req.Header.Set(
+		"Content-Disposition",
+		fmt.Sprintf("attachment; filename=%q", fileName(name, scheduled, rendered)),
+	)
+	for key, value := range cfg.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := uploadClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error uploading report: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("report upload failed with status %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package reporting
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// templateFuncs are the helpers available to every report template
+var templateFuncs = map[string]any{
+	"bytes":     formatBytes,
+	"percent":   formatPercent,
+	"duration":  formatDuration,
+	"sparkline": sparkline,
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. 1.5 GiB.
+// Integer and float arguments are accepted.
+func formatBytes(value any) string {
+	var b float64
+	switch v := value.(type) {
+	case uint64:
+		b = float64(v)
+	case int64:
+		b = float64(v)
+	case int:
+		b = float64(v)
+	case float64:
+		b = v
+	default:
+		return fmt.Sprint(value)
+	}
+
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+	i := 0
+	for math.Abs(b) >= 1024 && i < len(units)-1 {
+		b /= 1024
+		i++
+	}
+
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", b, units[i])
+	}
+	return fmt.Sprintf("%.1f %s", b, units[i])
+}
+
+// formatPercent renders a percentage with one decimal, e.g. 42.3%
+func formatPercent(value float64) string {
+	return fmt.Sprintf("%.1f%%", value)
+}
+
+// formatDuration renders a duration with its two largest units, e.g. 2d 3h or 5m
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.String()
+	}
+
+	d = d.Round(time.Second)
+	parts := []struct {
+		unit  string
+		value time.Duration
+	}{
+		{"d", d / (24 * time.Hour)},
+		{"h", d % (24 * time.Hour) / time.Hour},
+		{"m", d % time.Hour / time.Minute},
+		{"s", d % time.Minute / time.Second},
+	}
+
+	var out []string
+	for _, part := range parts {
+		if len(out) == 0 && part.value == 0 {
+			continue
+		}
+		if len(out) == 1 {
+			if part.value != 0 {
+				out = append(out, fmt.Sprintf("%d%s", part.value, part.unit))
+			}
+			break
+		}
+		out = append(out, fmt.Sprintf("%d%s", part.value, part.unit))
+	}
+
+	return strings.Join(out, " ")
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws values as a row of block characters. Long series are
+// averaged down to width characters (default 40).
+func sparkline(values []float64, width ...int) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	maxWidth := 40
+	if len(width) > 0 && width[0] > 0 {
+		maxWidth = width[0]
+	}
+
+	if len(values) > maxWidth {
+		buckets := make([]float64, maxWidth)
+		for i := range buckets {
+			start := i * len(values) / maxWidth
+			end := (i + 1) * len(values) / maxWidth
+			var sum float64
+			for _, v := range values[start:end] {
+				sum += v
+			}
+			buckets[i] = sum / float64(end-start)
+		}
+		values = buckets
+	}
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		low = math.Min(low, v)
+		high = math.Max(high, v)
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		i := 0
+		if high > low {
+			i = int((v - low) / (high - low) * float64(len(sparkBlocks)-1))
+		}
+		sb.WriteRune(sparkBlocks[i])
+	}
+
+	return sb.String()
+}
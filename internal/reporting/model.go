@@ -0,0 +1,363 @@
+package reporting
+
+import (
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"sys-monitor-report/internal/events"
+	"sys-monitor-report/internal/history"
+	"sys-monitor-report/internal/utils"
+	"time"
+)
+
+// topProcessCount is the number of processes listed per table in a report
+const topProcessCount = 10
+
+// Report is the data model passed to report templates.
+// Every field is documented in the README under "Report Templates".
+type Report struct {
+	Name      string    // Report name from the config
+	Host      string    // Hostname of the agent
+	From      time.Time // Start of the window (inclusive)
+	To        time.Time // End of the window (exclusive)
+	Generated time.Time // Time the report was rendered
+	Samples   int       // Number of snapshots in the window
+
+	Summary WindowSummary // Headline CPU, memory and swap statistics
+
+	// Series lists every metric series in the window, sorted by key
+	Series []SeriesSummary
+	// Metrics groups the same series by metric name,
+	// e.g. {{ index .Metrics "cpu_usage_percentage" }}
+	Metrics map[string][]SeriesSummary
+
+	TopCPUProcesses    []ProcessSummary // Highest average CPU usage
+	TopMemoryProcesses []ProcessSummary // Highest average memory usage
+	Partitions         []PartitionSummary
+	Alerts             []Alert // Threshold breaches in the window, oldest first
+}
+
+// Duration returns the length of the report window
+func (r Report) Duration() time.Duration {
+	return r.To.Sub(r.From)
+}
+
+// WindowSummary holds the headline statistics of a window
+type WindowSummary struct {
+	CPU           Stats // cpu_overall_usage, percent
+	Memory        Stats // overall_memory_usage used_percent (RAM and swap)
+	VirtualMemory Stats // virtual_memory_usage used_percent
+	Swap          Stats // swap_memory_usage used_percent
+}
+
+// Stats are aggregate values of one series
+type Stats struct {
+	Count int
+	Min   float64
+	Max   float64
+	Avg   float64
+	Last  float64
+}
+
+// Point is one value of a series
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// SeriesSummary holds aggregate values and raw points of one metric series
+type SeriesSummary struct {
+	Key    string // Name and labels, e.g. cpu_usage_percentage{core="core_1"}
+	Name   string
+	Labels map[string]string
+	Stats
+	Points []Point
+}
+
+// Values returns the point values, e.g. for {{ sparkline .Values }}
+func (s SeriesSummary) Values() []float64 {
+	values := make([]float64, len(s.Points))
+	for i, point := range s.Points {
+		values[i] = point.Value
+	}
+	return values
+}
+
+// ProcessSummary holds the usage of one process over the window
+type ProcessSummary struct {
+	PID     int32
+	Name    string
+	Avg     float64 // Average usage percentage while the process was in the top list
+	Max     float64 // Peak usage percentage
+	Samples int     // Number of snapshots the process appeared in
+}
+
+// PartitionSummary holds the state of one partition at the end of the window
+type PartitionSummary struct {
+	Device         string
+	Mountpoints    []string
+	Total          uint64  // Bytes
+	Used           uint64  // Bytes
+	Free           uint64  // Bytes
+	UsedPercent    float64 // At the end of the window
+	MaxUsedPercent float64 // Peak over the window
+	Usage          SeriesSummary
+}
+
+// Alert is a period during which a series stayed above its threshold
+type Alert struct {
+	Metric    string // cpu, steal, memory, memory_available, disk, disk_full, inodes, memory_pressure, io_pressure, close_wait, time_wait or temperature
+	Series    string // Series key
+	Labels    map[string]string
+	Threshold float64
+	Below     bool    // The alert fires below the threshold, e.g. for available memory
+	Peak      float64 // Most extreme value: the highest, or the lowest for Below alerts
+	Start     time.Time
+	End       time.Time // End of the window if the alert was still active
+	Active    bool      // Still above the threshold at the end of the window
+}
+
+// Duration returns how long the series stayed past the threshold
+func (a Alert) Duration() time.Duration {
+	return a.End.Sub(a.Start)
+}
+
+func hasLabels(series SeriesSummary, labels map[string]string) bool {
+	for name, value := range labels {
+		if series.Labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// BuildReport summarises the snapshots taken in [from, to)
+func BuildReport(
+	name string,
+	snapshots []history.Snapshot,
+	from, to time.Time,
+	thresholds utils.ThresholdsConfig,
+) Report {
+	host, _ := os.Hostname()
+
+	report := Report{
+		Name:      name,
+		Host:      host,
+		From:      from,
+		To:        to,
+		Generated: time.Now(),
+		Samples:   len(snapshots),
+		Metrics:   make(map[string][]SeriesSummary),
+	}
+
+	seriesMap := make(map[string]*SeriesSummary)
+	for _, snap := range snapshots {
+		for _, sample := range snap.Samples {
+			key := sample.SeriesKey()
+
+			series, exists := seriesMap[key]
+			if !exists {
+				series = &SeriesSummary{Key: key, Name: sample.Name, Labels: sample.Labels}
+				seriesMap[key] = series
+			}
+			series.Points = append(series.Points, Point{Time: snap.Time, Value: sample.Value})
+		}
+	}
+
+	for _, series := range seriesMap {
+		series.Stats = statsOf(series.Points)
+		report.Series = append(report.Series, *series)
+	}
+
+	sort.Slice(report.Series, func(i, j int) bool {
+		return report.Series[i].Key < report.Series[j].Key
+	})
+
+	for _, series := range report.Series {
+		report.Metrics[series.Name] = append(report.Metrics[series.Name], series)
+	}
+
+	report.Summary = WindowSummary{
+		CPU:           findStats(report.Metrics["cpu_overall_usage"], nil),
+		Memory:        findStats(report.Metrics["overall_memory_usage"], map[string]string{"type": "used_percent"}),
+		VirtualMemory: findStats(report.Metrics["virtual_memory_usage"], map[string]string{"type": "used_percent"}),
+		Swap:          findStats(report.Metrics["swap_memory_usage"], map[string]string{"type": "used_percent"}),
+	}
+
+	report.TopCPUProcesses = topProcesses(report.Metrics["process_cpu_usage"])
+	report.TopMemoryProcesses = topProcesses(report.Metrics["process_memory_usage"])
+	report.Partitions = partitions(report.Metrics)
+	var lastSnapshot time.Time
+	if len(snapshots) > 0 {
+		lastSnapshot = snapshots[len(snapshots)-1].Time
+	}
+	report.Alerts = alerts(report.Series, thresholds, lastSnapshot, to)
+
+	return report
+}
+
+func statsOf(points []Point) Stats {
+	if len(points) == 0 {
+		return Stats{}
+	}
+
+	stats := Stats{
+		Count: len(points),
+		Min:   math.Inf(1),
+		Max:   math.Inf(-1),
+		Last:  points[len(points)-1].Value,
+	}
+
+	var sum float64
+	for _, point := range points {
+		stats.Min = math.Min(stats.Min, point.Value)
+		stats.Max = math.Max(stats.Max, point.Value)
+		sum += point.Value
+	}
+	stats.Avg = sum / float64(len(points))
+
+	return stats
+}
+
+// findStats returns the stats of the first series carrying the given labels
+func findStats(series []SeriesSummary, labels map[string]string) Stats {
+	for _, s := range series {
+		if hasLabels(s, labels) {
+			return s.Stats
+		}
+	}
+	return Stats{}
+}
+
+// topProcesses ranks processes by their average usage over the window
+func topProcesses(series []SeriesSummary) []ProcessSummary {
+	var processes []ProcessSummary
+	for _, s := range series {
+		pid, _ := strconv.ParseInt(s.Labels["pid"], 10, 32)
+		processes = append(processes, ProcessSummary{
+			PID:     int32(pid),
+			Name:    s.Labels["name"],
+			Avg:     s.Avg,
+			Max:     s.Max,
+			Samples: s.Count,
+		})
+	}
+
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].Avg > processes[j].Avg
+	})
+
+	if len(processes) > topProcessCount {
+		processes = processes[:topProcessCount]
+	}
+
+	return processes
+}
+
+// partitions rebuilds per-device usage from partition_space and
+// partition_mountpoints, which are exported in GB
+func partitions(metrics map[string][]SeriesSummary) []PartitionSummary {
+	partitionMap := make(map[string]*PartitionSummary)
+	get := func(device string) *PartitionSummary {
+		if _, exists := partitionMap[device]; !exists {
+			partitionMap[device] = &PartitionSummary{Device: device}
+		}
+		return partitionMap[device]
+	}
+
+	for _, s := range metrics["partition_space"] {
+		part := get(s.Labels["device"])
+		switch s.Labels["type"] {
+		case "total_gb":
+			part.Total = uint64(s.Last * 1e9)
+		case "used_gb":
+			part.Used = uint64(s.Last * 1e9)
+		case "free_gb":
+			part.Free = uint64(s.Last * 1e9)
+		case "used_percent":
+			part.UsedPercent = s.Last
+			part.MaxUsedPercent = s.Max
+			part.Usage = s
+		}
+	}
+
+	for _, s := range metrics["partition_mountpoints"] {
+		part := get(s.Labels["device"])
+		part.Mountpoints = append(part.Mountpoints, s.Labels["mount"])
+	}
+
+	var result []PartitionSummary
+	for _, part := range partitionMap {
+		sort.Strings(part.Mountpoints)
+		result = append(result, *part)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Device < result[j].Device
+	})
+
+	return result
+}
+
+// alerts finds the periods in which series stayed past their thresholds. An
+// alert on a series that stopped being reported before the last snapshot,
+// e.g. a partition no longer filling up, ends with its last point.
+func alerts(series []SeriesSummary, thresholds utils.ThresholdsConfig, lastSnapshot, end time.Time) []Alert {
+	var result []Alert
+
+	for _, rule := range events.AlertRules {
+		threshold := float64(rule.Threshold(thresholds))
+		if threshold <= 0 {
+			continue
+		}
+
+		for _, s := range series {
+			if !rule.Matches(s.Name, s.Labels) {
+				continue
+			}
+
+			var current *Alert
+			for _, point := range s.Points {
+				if rule.Breached(point.Value, threshold) {
+					if current == nil {
+						current = &Alert{
+							Metric:    rule.Metric,
+							Series:    s.Key,
+							Labels:    s.Labels,
+							Threshold: threshold,
+							Below:     rule.Below,
+							Peak:      point.Value,
+							Start:     point.Time,
+						}
+					}
+					if rule.Below {
+						current.Peak = math.Min(current.Peak, point.Value)
+					} else {
+						current.Peak = math.Max(current.Peak, point.Value)
+					}
+				} else if current != nil {
+					current.End = point.Time
+					result = append(result, *current)
+					current = nil
+				}
+			}
+
+			if current != nil {
+				if last := s.Points[len(s.Points)-1].Time; last.Before(lastSnapshot) {
+					current.End = last
+				} else {
+					current.End = end
+					current.Active = true
+				}
+				result = append(result, *current)
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
+
+	return result
+}
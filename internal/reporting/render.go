@@ -0,0 +1,129 @@
+package reporting
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Rendered is a report ready for delivery
+type Rendered struct {
+	Body        []byte
+	ContentType string
+	Extension   string
+}
+
+// Template renders a report. Files ending in .html or .htm are parsed with
+// html/template so values are escaped; everything else uses text/template.
+type Template struct {
+	executor    interface{ Execute(io.Writer, any) error }
+	contentType string
+	extension   string
+}
+
+const defaultTemplate = `System report: {{ .Name }}
+Host:      {{ .Host }}
+Window:    {{ .From.Format "2006-01-02 15:04 MST" }} - {{ .To.Format "2006-01-02 15:04 MST" }} ({{ duration .Duration }})
+Generated: {{ .Generated.Format "2006-01-02 15:04:05 MST" }}
+Samples:   {{ .Samples }}
+{{ if not .Series }}
+No samples were collected in this window.
+{{ else }}
+{{ with .Summary -}}
+CPU:     avg {{ percent .CPU.Avg }}, max {{ percent .CPU.Max }}
+Memory:  avg {{ percent .VirtualMemory.Avg }}, max {{ percent .VirtualMemory.Max }}
+Swap:    avg {{ percent .Swap.Avg }}, max {{ percent .Swap.Max }}
+{{ end }}
+{{- with index .Metrics "cpu_overall_usage" }}{{ with index . 0 }}
+CPU      {{ sparkline .Values }}
+{{ end }}{{ end }}
+{{- if .Partitions }}
+{{ printf "%-30s %12s %12s %8s %8s" "PARTITION" "USED" "TOTAL" "USED%" "PEAK%" }}
+{{ range .Partitions -}}
+{{ printf "%-30s %12s %12s %8s %8s" .Device (bytes .Used) (bytes .Total) (percent .UsedPercent) (percent .MaxUsedPercent) }}
+{{ end }}{{ end }}
+{{- if .TopCPUProcesses }}
+{{ printf "%-8s %-30s %8s %8s" "PID" "TOP CPU PROCESSES" "AVG%" "MAX%" }}
+{{ range .TopCPUProcesses -}}
+{{ printf "%-8d %-30s %8.1f %8.1f" .PID .Name .Avg .Max }}
+{{ end }}{{ end }}
+{{- if .TopMemoryProcesses }}
+{{ printf "%-8s %-30s %8s %8s" "PID" "TOP MEMORY PROCESSES" "AVG%" "MAX%" }}
+{{ range .TopMemoryProcesses -}}
+{{ printf "%-8d %-30s %8.1f %8.1f" .PID .Name .Avg .Max }}
+{{ end }}{{ end }}
+{{- if .Alerts }}
+ALERTS
+{{ range .Alerts -}}
+{{ .Start.Format "01-02 15:04" }}  {{ .Series }} {{ if .Below }}below{{ else }}above{{ end }} {{ .Threshold }} for {{ duration .Duration }}, peak {{ printf "%.2f" .Peak }}{{ if .Active }} (still active){{ end }}
+{{ end }}{{ end }}
+{{- end -}}
+`
+
+var defaultReportTemplate = &Template{
+	executor: texttemplate.Must(
+		texttemplate.New("default").Funcs(templateFuncs).Parse(defaultTemplate),
+	),
+	contentType: "text/plain; charset=utf-8",
+	extension:   ".txt",
+}
+
+// LoadTemplate parses a user-supplied report template file
+func LoadTemplate(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading report template: %v", err)
+	}
+
+	name := filepath.Base(path)
+	extension := strings.ToLower(filepath.Ext(path))
+
+	switch extension {
+	case ".html", ".htm":
+		tmpl, err := htmltemplate.New(name).Funcs(templateFuncs).Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing report template %s: %v", path, err)
+		}
+		return &Template{
+			executor:    tmpl,
+			contentType: "text/html; charset=utf-8",
+			extension:   ".html",
+		}, nil
+	default:
+		tmpl, err := texttemplate.New(name).Funcs(templateFuncs).Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing report template %s: %v", path, err)
+		}
+		if extension == "" {
+			extension = ".txt"
+		}
+		contentType := "text/plain; charset=utf-8"
+		if extension == ".md" {
+			contentType = "text/markdown; charset=utf-8"
+		}
+		return &Template{
+			executor:    tmpl,
+			contentType: contentType,
+			extension:   extension,
+		}, nil
+	}
+}
+
+// Render executes the template against a report
+func (t *Template) Render(report Report) (Rendered, error) {
+	var buf bytes.Buffer
+	if err := t.executor.Execute(&buf, report); err != nil {
+		return Rendered{}, fmt.Errorf("error rendering report %s: %v", report.Name, err)
+	}
+
+	return Rendered{
+		Body:        buf.Bytes(),
+		ContentType: t.contentType,
+		Extension:   t.extension,
+	}, nil
+}
@@ -0,0 +1,95 @@
+package reporting
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Run records one execution of a scheduled report
+type Run struct {
+	Report     string    `json:"report"`
+	Scheduled  time.Time `json:"scheduled"`
+	WindowFrom time.Time `json:"window_from"`
+	WindowTo   time.Time `json:"window_to"`
+	Started    time.Time `json:"started"`
+	Finished   time.Time `json:"finished"`
+	CatchUp    bool      `json:"catch_up,omitempty"` // Run was missed and executed late
+	Status     string    `json:"status"`             // ok or error
+	Errors     []string  `json:"errors,omitempty"`
+	Outputs    []string  `json:"outputs,omitempty"` // Files written and targets delivered to
+}
+
+// RunLog is an append-only JSON Lines file of report runs
+type RunLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewRunLog(path string) (*RunLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("error creating run log directory: %v", err)
+	}
+	return &RunLog{path: path}, nil
+}
+
+// Append adds a run to the log
+func (l *RunLog) Append(run Run) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	line, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("error encoding report run: %v", err)
+	}
+
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening run log: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing run log: %v", err)
+	}
+
+	return nil
+}
+
+// LastScheduled returns the latest scheduled time recorded for each report
+func (l *RunLog) LastScheduled() (map[string]time.Time, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	last := make(map[string]time.Time)
+
+	file, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return last, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening run log: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			continue
+		}
+		if run.Scheduled.After(last[run.Report]) {
+			last[run.Report] = run.Scheduled
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading run log: %v", err)
+	}
+
+	return last, nil
+}
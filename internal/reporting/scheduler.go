@@ -0,0 +1,219 @@
+package reporting
+
+import (
+	"fmt"
+	"sys-monitor-report/internal/history"
+	"sys-monitor-report/internal/utils"
+	"time"
+)
+
+// maxCatchUp limits how many missed runs of one report are executed after
+// downtime; older ones are skipped
+const maxCatchUp = 31
+
+type job struct {
+	cfg      utils.ReportConfig
+	schedule Schedule
+	template *Template
+	next     time.Time
+}
+
+// Scheduler renders and delivers the configured reports on their schedules
+type Scheduler struct {
+	jobs       []*job
+	store      *history.Store
+	runLog     *RunLog
+	thresholds utils.ThresholdsConfig
+}
+
+// NewScheduler validates the report definitions
+func NewScheduler(
+	reports []utils.ReportConfig,
+	thresholds utils.ThresholdsConfig,
+	store *history.Store,
+	runLog *RunLog,
+) (*Scheduler, error) {
+	scheduler := &Scheduler{store: store, runLog: runLog, thresholds: thresholds}
+	names := make(map[string]bool)
+
+	for _, cfg := range reports {
+		if cfg.Name == "" {
+			return nil, fmt.Errorf("report without a name")
+		}
+		if names[cfg.Name] {
+			return nil, fmt.Errorf("duplicate report name %q", cfg.Name)
+		}
+		names[cfg.Name] = true
+
+		if cfg.Directory == nil && cfg.Email == nil && cfg.HTTP == nil {
+			return nil, fmt.Errorf("report %q has no delivery configured", cfg.Name)
+		}
+
+		schedule, err := ParseSchedule(cfg.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("report %q: %v", cfg.Name, err)
+		}
+
+		tmpl := defaultReportTemplate
+		if cfg.Template != "" {
+			if tmpl, err = LoadTemplate(cfg.Template); err != nil {
+				return nil, fmt.Errorf("report %q: %v", cfg.Name, err)
+			}
+		}
+
+		scheduler.jobs = append(scheduler.jobs, &job{cfg: cfg, schedule: schedule, template: tmpl})
+	}
+
+	return scheduler, nil
+}
+
+// Run catches up on runs missed while the agent was down, then executes
+// reports as they become due until stop is closed
+func (s *Scheduler) Run(stop <-chan struct{}) {
+	if len(s.jobs) == 0 {
+		return
+	}
+
+	now := time.Now()
+	s.catchUp(now)
+	for _, j := range s.jobs {
+		j.next = j.schedule.Next(now)
+	}
+
+	for {
+		var earliest time.Time
+		for _, j := range s.jobs {
+			if !j.next.IsZero() && (earliest.IsZero() || j.next.Before(earliest)) {
+				earliest = j.next
+			}
+		}
+		if earliest.IsZero() {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(earliest))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// A run is late if the host was suspended past its scheduled time
+		now := time.Now()
+		for _, j := range s.jobs {
+			for !j.next.IsZero() && !j.next.After(now) {
+				s.execute(j, j.next, now.Sub(j.next) > time.Minute)
+				j.next = j.schedule.Next(j.next)
+			}
+		}
+	}
+}
+
+// catchUp executes runs scheduled between the last logged run and now
+func (s *Scheduler) catchUp(now time.Time) {
+	last, err := s.runLog.LastScheduled()
+	if err != nil {
+		fmt.Printf("Error reading report run log: %v\n", err)
+		return
+	}
+
+	for _, j := range s.jobs {
+		lastRun, exists := last[j.cfg.Name]
+		if !exists {
+			// Never run before: start with the next scheduled time
+			continue
+		}
+
+		var missed []time.Time
+		for t := j.schedule.Next(lastRun); !t.IsZero() && !t.After(now); t = j.schedule.Next(t) {
+			missed = append(missed, t)
+		}
+
+		if len(missed) > maxCatchUp {
+			fmt.Printf(
+				"Report %s missed %d runs, catching up on the last %d\n",
+				j.cfg.Name, len(missed), maxCatchUp,
+			)
+			missed = missed[len(missed)-maxCatchUp:]
+		}
+
+		for _, scheduled := range missed {
+			s.execute(j, scheduled, true)
+		}
+	}
+}
+
+// execute renders the report for the window ending at scheduled and delivers it
+func (s *Scheduler) execute(j *job, scheduled time.Time, catchUp bool) {
+	from := j.schedule.Prev(scheduled)
+	if j.cfg.WindowHours > 0 || from.IsZero() {
+		from = scheduled.Add(-time.Duration(j.cfg.WindowHours) * time.Hour)
+	}
+
+	run := Run{
+		Report:     j.cfg.Name,
+		Scheduled:  scheduled,
+		WindowFrom: from,
+		WindowTo:   scheduled,
+		Started:    time.Now(),
+		CatchUp:    catchUp,
+	}
+
+	s.deliver(j, &run)
+
+	run.Finished = time.Now()
+	run.Status = "ok"
+	if len(run.Errors) > 0 {
+		run.Status = "error"
+		for _, err := range run.Errors {
+			fmt.Printf("Error running report %s: %s\n", j.cfg.Name, err)
+		}
+	} else {
+		fmt.Printf("Report %s for %s delivered\n", j.cfg.Name, scheduled.Format(time.RFC3339))
+	}
+
+	if err := s.runLog.Append(run); err != nil {
+		fmt.Printf("Error recording report run: %v\n", err)
+	}
+}
+
+func (s *Scheduler) deliver(j *job, run *Run) {
+	snapshots, err := s.store.Query(run.WindowFrom, run.WindowTo)
+	if err != nil {
+		run.Errors = append(run.Errors, err.Error())
+		return
+	}
+
+	report := BuildReport(j.cfg.Name, snapshots, run.WindowFrom, run.WindowTo, s.thresholds)
+	rendered, err := j.template.Render(report)
+	if err != nil {
+		run.Errors = append(run.Errors, err.Error())
+		return
+	}
+
+	if j.cfg.Directory != nil {
+		path, err := DeliverToDirectory(j.cfg.Directory, j.cfg.Name, run.Scheduled, rendered)
+		if err != nil {
+			run.Errors = append(run.Errors, err.Error())
+		} else {
+			run.Outputs = append(run.Outputs, path)
+		}
+	}
+
+	if j.cfg.Email != nil {
+		if err := DeliverByEmail(j.cfg.Email, j.cfg.Name, report, rendered); err != nil {
+			run.Errors = append(run.Errors, err.Error())
+		} else {
+			run.Outputs = append(run.Outputs, "email")
+		}
+	}
+
+	if j.cfg.HTTP != nil {
+		if err := DeliverByHTTP(j.cfg.HTTP, j.cfg.Name, run.Scheduled, rendered); err != nil {
+			run.Errors = append(run.Errors, err.Error())
+		} else {
+			run.Outputs = append(run.Outputs, j.cfg.HTTP.URL)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package sinks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// batcher buffers encoded items in memory and hands them to a send function
+// in batches, either when a batch fills up or on every flush interval.
+// Failed batches are retried with backoff and stay buffered afterwards;
+// once the buffer is full the oldest items are dropped so producers never block.
+// A failing sink is only reported when it starts failing and when it
+// recovers, and dropped items at most every dropReportInterval, so an
+// unreachable receiver does not flood the log on every flush.
+type batcher struct {
+	name       string
+	maxItems   int
+	batchSize  int
+	interval   time.Duration
+	maxRetries int
+	send       func(ctx context.Context, items [][]byte) error
+
+	mu      sync.Mutex
+	items   [][]byte
+	dropped int
+	removed int // Items ever removed from the front of the buffer
+
+	failing    bool      // The last batch could not be sent
+	lastReport time.Time // When dropped items were last reported
+
+	kick   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// dropReportInterval is the shortest time between two reports of dropped
+// items
+const dropReportInterval = time.Minute
+
+func newBatcher(
+	name string,
+	maxItems, batchSize int,
+	interval time.Duration,
+	maxRetries int,
+	send func(ctx context.Context, items [][]byte) error,
+) *batcher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	b := &batcher{
+		name:       name,
+		maxItems:   maxItems,
+		batchSize:  batchSize,
+		interval:   interval,
+		maxRetries: maxRetries,
+		send:       send,
+		kick:       make(chan struct{}, 1),
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+
+	go b.run(ctx)
+
+	return b
+}
+
+// add queues items, dropping the oldest ones if the buffer is full
+func (b *batcher) add(items ...[]byte) {
+	b.mu.Lock()
+	b.items = append(b.items, items...)
+	if over := len(b.items) - b.maxItems; over > 0 {
+		b.items = b.items[over:]
+		b.dropped += over
+		b.removed += over
+	}
+	full := len(b.items) >= b.batchSize
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// close stops the flush loop after one last attempt to send what is buffered
+func (b *batcher) close() {
+	b.cancel()
+	<-b.done
+}
+
+func (b *batcher) run(ctx context.Context) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			b.flush(shutdown, 0)
+			cancel()
+			return
+		case <-ticker.C:
+		case <-b.kick:
+		}
+
+		b.flush(ctx, b.maxRetries)
+	}
+}
+
+// isBatcherLine reports whether a log line was printed by the batcher of the
+// named sink, so a sink shipping the agent's output can leave out its own
+// delivery errors
+func isBatcherLine(name, line string) bool {
+	return strings.HasPrefix(line, name+" buffer full,") ||
+		strings.HasPrefix(line, "Error sending "+name+" batch,") ||
+		strings.HasPrefix(line, "Sending "+name+" batches again") ||
+		strings.HasPrefix(line, "Dropping "+name+" batch:")
+}
+
+// flush sends buffered items batch by batch until the buffer is empty or a
+// batch keeps failing
+func (b *batcher) flush(ctx context.Context, retries int) {
+	b.mu.Lock()
+	if b.dropped > 0 && time.Since(b.lastReport) >= dropReportInterval {
+		fmt.Printf("%s buffer full, dropped %d items\n", b.name, b.dropped)
+		b.dropped = 0
+		b.lastReport = time.Now()
+	}
+	b.mu.Unlock()
+
+	for {
+		b.mu.Lock()
+		n := min(len(b.items), b.batchSize)
+		batch := b.items[:n:n]
+		start := b.removed
+		b.mu.Unlock()
+
+		if n == 0 {
+			return
+		}
+
+		err := b.sendWithRetry(ctx, batch, retries)
+		if err != nil {
+			if _, permanent := err.(permanentError); !permanent {
+				if !b.failing {
+					fmt.Printf("Error sending %s batch, keeping %d items buffered: %v\n", b.name, n, err)
+					b.failing = true
+				}
+				return
+			}
+			fmt.Printf("Dropping %s batch: %v\n", b.name, err)
+		} else if b.failing {
+			fmt.Printf("Sending %s batches again\n", b.name)
+			b.failing = false
+		}
+
+		b.mu.Lock()
+		// Part of the batch may have been dropped while it was being sent
+		if remaining := n - (b.removed - start); remaining > 0 {
+			b.items = b.items[remaining:]
+			b.removed += remaining
+		}
+		b.mu.Unlock()
+	}
+}
+
+func (b *batcher) sendWithRetry(ctx context.Context, batch [][]byte, retries int) error {
+	backoff := time.Second
+
+	for attempt := 0; ; attempt++ {
+		err := b.send(ctx, batch)
+		if err == nil {
+			return nil
+		}
+		if _, permanent := err.(permanentError); permanent || attempt >= retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,141 @@
+package sinks
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	dialTimeout  = 5 * time.Second
+	writeTimeout = 5 * time.Second
+	maxRedial    = time.Minute
+)
+
+// reconnectingConn is a TCP, TLS, UDP or Unix datagram connection that is
+// dialled lazily and re-dialled with backoff after a failure. It is only used
+// from one goroutine.
+type reconnectingConn struct {
+	network   string
+	address   string
+	tlsConfig *tls.Config // Only used for tls
+
+	conn     net.Conn
+	nextDial time.Time
+	backoff  time.Duration
+}
+
+func newReconnectingConn(network, address string) (*reconnectingConn, error) {
+	switch network {
+	case "tcp", "udp", "tls":
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return nil, fmt.Errorf("invalid address %q: %v", address, err)
+		}
+	case "unixgram":
+		if address == "" {
+			return nil, fmt.Errorf("missing socket path")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported protocol: %s", network)
+	}
+
+	return &reconnectingConn{network: network, address: address}, nil
+}
+
+// datagram reports whether every write is sent as a separate packet
+func (c *reconnectingConn) datagram() bool {
+	return c.network == "udp" || c.network == "unixgram"
+}
+
+// writeLines sends newline-terminated lines. Over UDP lines are packed into
+// datagrams of at most packetSize bytes.
+func (c *reconnectingConn) writeLines(lines [][]byte, packetSize int) error {
+	if err := c.connect(); err != nil {
+		return err
+	}
+
+	var buf []byte
+	for _, line := range lines {
+		if c.network == "udp" && len(buf) > 0 && len(buf)+len(line)+1 > packetSize {
+			if err := c.write(buf); err != nil {
+				return err
+			}
+			buf = buf[:0]
+		}
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+
+	if len(buf) > 0 {
+		return c.write(buf)
+	}
+	return nil
+}
+
+// writeFrames sends already framed messages, one datagram each over UDP and
+// Unix sockets and back to back over streams
+func (c *reconnectingConn) writeFrames(frames [][]byte) error {
+	if err := c.connect(); err != nil {
+		return err
+	}
+
+	if c.datagram() {
+		for _, frame := range frames {
+			if err := c.write(frame); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var buf []byte
+	for _, frame := range frames {
+		buf = append(buf, frame...)
+	}
+	return c.write(buf)
+}
+
+func (c *reconnectingConn) connect() error {
+	if c.conn != nil {
+		return nil
+	}
+	if time.Now().Before(c.nextDial) {
+		return fmt.Errorf("not connected to %s, next attempt at %s", c.address, c.nextDial.Format(time.TimeOnly))
+	}
+
+	var conn net.Conn
+	var err error
+	if c.network == "tls" {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", c.address, c.tlsConfig)
+	} else {
+		conn, err = net.DialTimeout(c.network, c.address, dialTimeout)
+	}
+	if err != nil {
+		c.backoff = min(max(c.backoff*2, time.Second), maxRedial)
+		c.nextDial = time.Now().Add(c.backoff)
+		return fmt.Errorf("error connecting to %s: %v", c.address, err)
+	}
+
+	c.conn = conn
+	c.backoff = 0
+	return nil
+}
+
+func (c *reconnectingConn) write(buf []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := c.conn.Write(buf); err != nil {
+		c.close()
+		return fmt.Errorf("error writing to %s: %v", c.address, err)
+	}
+	return nil
+}
+
+func (c *reconnectingConn) close() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
@@ -0,0 +1,129 @@
+package sinks
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sys-monitor-report/internal/history"
+	"sys-monitor-report/internal/utils"
+	"time"
+)
+
+// Defaults shared by the Graphite and StatsD sinks
+const (
+	defaultPathPrefix  = "sysmon"
+	defaultFlushPeriod = time.Second
+	defaultBufferLines = 50000
+)
+
+// Graphite sends snapshots over the Graphite plaintext protocol
+// ("path value timestamp" lines) via TCP or UDP
+type Graphite struct {
+	prefix  string
+	conn    *reconnectingConn
+	batcher *batcher
+}
+
+// NewGraphite configures the path prefix and connection
+func NewGraphite(cfg utils.GraphiteConfig) (*Graphite, error) {
+	if cfg.Protocol == "" {
+		cfg.Protocol = "tcp"
+	}
+
+	conn, err := newReconnectingConn(cfg.Protocol, cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("graphite: %v", err)
+	}
+
+	g := &Graphite{
+		prefix: pathPrefix(cfg.Prefix, cfg.Host),
+		conn:   conn,
+	}
+	g.batcher = newBatcher(
+		"graphite",
+		defaultBufferLines,
+		defaultBufferLines/10,
+		defaultFlushPeriod,
+		0,
+		g.send,
+	)
+
+	return g, nil
+}
+
+// Write queues one line per sample
+func (g *Graphite) Write(snap history.Snapshot) {
+	timestamp := strconv.FormatInt(snap.Time.Unix(), 10)
+
+	lines := make([][]byte, 0, len(snap.Samples))
+	for _, sample := range snap.Samples {
+		lines = append(lines, []byte(
+			g.prefix+metricPath(sample)+" "+
+				strconv.FormatFloat(sample.Value, 'f', -1, 64)+" "+timestamp,
+		))
+	}
+
+	g.batcher.add(lines...)
+}
+
+// Close flushes buffered lines and closes the connection
+func (g *Graphite) Close() error {
+	g.batcher.close()
+	return g.conn.close()
+}
+
+func (g *Graphite) send(_ context.Context, lines [][]byte) error {
+	return g.conn.writeLines(lines, udpPayloadSize)
+}
+
+var pathUnsafe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// pathSegment makes a value usable as one dotted path component
+func pathSegment(value string) string {
+	return strings.Trim(pathUnsafe.ReplaceAllString(value, "_"), "_")
+}
+
+// pathPrefix builds the "<prefix>.<host>." part of every path. The host
+// segment defaults to the hostname with dots replaced.
+func pathPrefix(prefix, host string) string {
+	if prefix == "" {
+		prefix = defaultPathPrefix
+	}
+	if host == "" {
+		host = hostname()
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(prefix, ".") {
+		if segment = pathSegment(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	segments = append(segments, pathSegment(host))
+
+	return strings.Join(segments, ".") + "."
+}
+
+// metricPath flattens a sample into the metric name followed by its label
+// values ordered by label name, e.g.
+// partition_space{device="/dev/sda1",type="used_gb"} becomes
+// partition_space.dev_sda1.used_gb
+func metricPath(sample history.Sample) string {
+	names := make([]string, 0, len(sample.Labels))
+	for name := range sample.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	segments := []string{pathSegment(sample.Name)}
+	for _, name := range names {
+		if segment := pathSegment(sample.Labels[name]); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	return strings.Join(segments, ".")
+}
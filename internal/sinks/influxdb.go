@@ -0,0 +1,275 @@
+package sinks
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sys-monitor-report/internal/history"
+	"sys-monitor-report/internal/utils"
+	"time"
+)
+
+// udpPayloadSize keeps UDP datagrams below a typical MTU
+const udpPayloadSize = 1400
+
+// InfluxDB writes snapshots in InfluxDB line protocol, either to the v2 HTTP
+// write API or to a UDP listener
+type InfluxDB struct {
+	cfg      utils.InfluxDBConfig
+	tags     map[string]string
+	writeURL string
+	client   *http.Client
+	conn     net.Conn
+	batcher  *batcher
+}
+
+// NewInfluxDB configures the transport from the URL scheme
+// (http, https or udp) and starts the batcher
+func NewInfluxDB(cfg utils.InfluxDBConfig) (*InfluxDB, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("influxdb url is required")
+	}
+	if cfg.FieldLabel == "" {
+		cfg.FieldLabel = "type"
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 5000
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 10
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 100000
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10
+	}
+	retries := 3
+	if cfg.MaxRetries != nil {
+		retries = max(*cfg.MaxRetries, 0)
+	}
+
+	target, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid influxdb url: %v", err)
+	}
+
+	tags := map[string]string{"host": hostname()}
+	for name, value := range cfg.Tags {
+		tags[name] = value
+	}
+
+	influx := &InfluxDB{cfg: cfg, tags: tags}
+	send := influx.sendHTTP
+
+	switch target.Scheme {
+	case "http", "https":
+		if cfg.Bucket == "" {
+			return nil, fmt.Errorf("influxdb bucket is required for the HTTP API")
+		}
+		query := url.Values{}
+		query.Set("org", cfg.Org)
+		query.Set("bucket", cfg.Bucket)
+		query.Set("precision", "ns")
+		target.Path = strings.TrimSuffix(target.Path, "/") + "/api/v2/write"
+		target.RawQuery = query.Encode()
+
+		influx.writeURL = target.String()
+		influx.client = &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second}
+	case "udp":
+		// UDP is fire-and-forget: no retries and no compression
+		influx.conn, err = net.Dial("udp", target.Host)
+		if err != nil {
+			return nil, fmt.Errorf("error opening influxdb UDP socket: %v", err)
+		}
+		retries = 0
+		send = influx.sendUDP
+	default:
+		return nil, fmt.Errorf("unsupported influxdb url scheme: %s", target.Scheme)
+	}
+
+	influx.batcher = newBatcher(
+		"influxdb",
+		cfg.BufferSize,
+		cfg.BatchSize,
+		time.Duration(cfg.FlushInterval)*time.Second,
+		retries,
+		send,
+	)
+
+	return influx, nil
+}
+
+// Write converts a snapshot to line protocol and queues the lines
+func (i *InfluxDB) Write(snap history.Snapshot) {
+	i.batcher.add(i.lines(snap)...)
+}
+
+// Close flushes buffered lines
+func (i *InfluxDB) Close() error {
+	i.batcher.close()
+	if i.conn != nil {
+		return i.conn.Close()
+	}
+	return nil
+}
+
+// lines groups the samples of a snapshot into one line per metric and tag
+// set. Labels become tags, except the field label (type by default), whose
+// values become field keys: partition_space{device,type="used_gb"} turns into
+// partition_space,device=/dev/sda1,host=web-1 used_gb=12.5,free_gb=...
+func (i *InfluxDB) lines(snap history.Snapshot) [][]byte {
+	type point struct {
+		measurement string
+		tags        string
+		fields      []string
+	}
+
+	points := make(map[string]*point)
+	var order []string
+
+	for _, sample := range snap.Samples {
+		tags := make(map[string]string, len(i.tags)+len(sample.Labels))
+		for name, value := range i.tags {
+			tags[name] = value
+		}
+
+		field := "value"
+		for name, value := range sample.Labels {
+			if name == i.cfg.FieldLabel {
+				field = value
+				continue
+			}
+			tags[name] = value
+		}
+
+		tagSet := formatTags(tags)
+		key := sample.Name + tagSet
+
+		p, exists := points[key]
+		if !exists {
+			p = &point{measurement: escapeMeasurement(sample.Name), tags: tagSet}
+			points[key] = p
+			order = append(order, key)
+		}
+		p.fields = append(
+			p.fields,
+			escapeKey(field)+"="+strconv.FormatFloat(sample.Value, 'f', -1, 64),
+		)
+	}
+
+	// Nanoseconds, the precision set on the HTTP API and the default of
+	// UDP listeners, which take no precision parameter
+	timestamp := strconv.FormatInt(snap.Time.UnixNano(), 10)
+	lines := make([][]byte, 0, len(order))
+	for _, key := range order {
+		p := points[key]
+		lines = append(lines, []byte(p.measurement+p.tags+" "+strings.Join(p.fields, ",")+" "+timestamp))
+	}
+
+	return lines
+}
+
+// formatTags renders tags sorted by key, as InfluxDB recommends
+func formatTags(tags map[string]string) string {
+	names := make([]string, 0, len(tags))
+	for name, value := range tags {
+		// Empty tag values are not allowed in line protocol
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteString(",")
+		sb.WriteString(escapeKey(name))
+		sb.WriteString("=")
+		sb.WriteString(escapeKey(tags[name]))
+	}
+	return sb.String()
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	keyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+func escapeMeasurement(s string) string { return measurementEscaper.Replace(s) }
+
+// escapeKey escapes tag keys, tag values and field keys
+func escapeKey(s string) string { return keyEscaper.Replace(s) }
+
+func (i *InfluxDB) sendHTTP(ctx context.Context, lines [][]byte) error {
+	payload := append(bytes.Join(lines, []byte("\n")), '\n')
+
+	var body bytes.Buffer
+	if i.cfg.Gzip {
+		gz := gzip.NewWriter(&body)
+		gz.Write(payload)
+		if err := gz.Close(); err != nil {
+			return permanentError{err}
+		}
+	} else {
+		body.Write(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.writeURL, &body)
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if i.cfg.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if i.cfg.Token != "" {
+		req.Header.Set("Authorization", "Token "+i.cfg.Token)
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	switch {
+	case resp.StatusCode/100 == 2:
+		return nil
+	case resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests:
+		return permanentError{fmt.Errorf("influxdb returned %s: %s", resp.Status, bytes.TrimSpace(message))}
+	default:
+		return fmt.Errorf("influxdb returned %s: %s", resp.Status, bytes.TrimSpace(message))
+	}
+}
+
+// sendUDP packs lines into datagrams of at most udpPayloadSize bytes
+func (i *InfluxDB) sendUDP(_ context.Context, lines [][]byte) error {
+	var packet []byte
+	for _, line := range lines {
+		if len(packet) > 0 && len(packet)+len(line)+1 > udpPayloadSize {
+			if _, err := i.conn.Write(packet); err != nil {
+				return permanentError{err}
+			}
+			packet = packet[:0]
+		}
+		packet = append(packet, line...)
+		packet = append(packet, '\n')
+	}
+
+	if len(packet) > 0 {
+		if _, err := i.conn.Write(packet); err != nil {
+			return permanentError{err}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package sinks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sys-monitor-report/internal/events"
+	"sys-monitor-report/internal/utils"
+	"time"
+)
+
+// Loki pushes events and the agent's log lines to the Loki push API. Each
+// entry is labelled with job, host, collector, kind and severity; event
+// details are written as logfmt so they can be parsed in LogQL.
+type Loki struct {
+	cfg     utils.LokiConfig
+	pushURL string
+	labels  map[string]string
+	client  *http.Client
+	batcher *batcher
+}
+
+// lokiEntry is one buffered log line with its stream labels
+type lokiEntry struct {
+	Labels map[string]string `json:"labels"`
+	Time   int64             `json:"time"` // Unix nanoseconds
+	Line   string            `json:"line"`
+}
+
+// NewLoki configures the push URL and starts the batcher
+func NewLoki(cfg utils.LokiConfig) (*Loki, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("loki url is required")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1000
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 5
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 10000
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10
+	}
+	retries := 5
+	if cfg.MaxRetries != nil {
+		retries = max(*cfg.MaxRetries, 0)
+	}
+
+	labels := map[string]string{"job": "sys-monitor", "host": hostname()}
+	for name, value := range cfg.Labels {
+		labels[name] = value
+	}
+
+	l := &Loki{
+		cfg:     cfg,
+		pushURL: strings.TrimSuffix(cfg.URL, "/") + "/loki/api/v1/push",
+		labels:  labels,
+		client:  &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+	}
+	l.batcher = newBatcher(
+		"loki",
+		cfg.BufferSize,
+		cfg.BatchSize,
+		time.Duration(cfg.FlushInterval)*time.Second,
+		retries,
+		l.send,
+	)
+
+	return l, nil
+}
+
+// Event queues a logfmt line for the event, e.g.
+// metric=cpu_overall_usage value=95.5 threshold=90 state=firing msg="cpu alert firing: ..."
+func (l *Loki) Event(e events.Event) {
+	var line strings.Builder
+	writeLogfmt(&line, "metric", e.Metric)
+	if e.Kind != events.KindCollectorError {
+		writeLogfmt(&line, "value", strconv.FormatFloat(e.Value, 'f', -1, 64))
+		writeLogfmt(&line, "threshold", strconv.FormatFloat(e.Threshold, 'f', -1, 64))
+	}
+	writeLogfmt(&line, "state", e.State)
+	writeLogfmt(&line, "msg", e.Message)
+
+	l.queue(e.Time, line.String(), map[string]string{
+		"collector": e.Collector,
+		"kind":      e.Kind,
+		"severity":  e.Severity,
+	})
+}
+
+// Log queues one line of the agent's own output. The sink's own delivery
+// errors are left out: while Loki is down they would push the buffered events
+// out of the buffer.
+func (l *Loki) Log(line string) {
+	if isBatcherLine("loki", line) {
+		return
+	}
+	l.queue(time.Now(), line, map[string]string{
+		"collector": "agent",
+		"kind":      "log",
+		"severity":  logSeverity(line),
+	})
+}
+
+// Close pushes buffered entries
+func (l *Loki) Close() error {
+	l.batcher.close()
+	return nil
+}
+
+// queue buffers one entry. The entry's own labels (collector, kind and
+// severity) take precedence over the configured ones of the same name.
+func (l *Loki) queue(t time.Time, line string, entryLabels map[string]string) {
+	labels := make(map[string]string, len(l.labels)+len(entryLabels))
+	for _, set := range []map[string]string{l.labels, entryLabels} {
+		for name, value := range set {
+			// Loki rejects empty label values
+			if value != "" {
+				labels[name] = value
+			}
+		}
+	}
+
+	entry, err := json.Marshal(lokiEntry{Labels: labels, Time: t.UnixNano(), Line: line})
+	if err != nil {
+		return
+	}
+	l.batcher.add(entry)
+}
+
+// send groups the entries into streams by label set and pushes them
+func (l *Loki) send(ctx context.Context, items [][]byte) error {
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+
+	streams := make(map[string]*stream)
+	var order []string
+
+	for _, item := range items {
+		var entry lokiEntry
+		if err := json.Unmarshal(item, &entry); err != nil {
+			continue
+		}
+
+		key := labelSetKey(entry.Labels)
+		s, exists := streams[key]
+		if !exists {
+			s = &stream{Stream: entry.Labels}
+			streams[key] = s
+			order = append(order, key)
+		}
+		s.Values = append(s.Values, [2]string{strconv.FormatInt(entry.Time, 10), entry.Line})
+	}
+
+	push := struct {
+		Streams []*stream `json:"streams"`
+	}{}
+	for _, key := range order {
+		push.Streams = append(push.Streams, streams[key])
+	}
+
+	body, err := json.Marshal(push)
+	if err != nil {
+		return permanentError{err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.pushURL, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sys-monitor-report")
+	if l.cfg.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", l.cfg.TenantID)
+	}
+	if l.cfg.Username != "" {
+		req.SetBasicAuth(l.cfg.Username, l.cfg.Password)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	switch {
+	case resp.StatusCode/100 == 2:
+		return nil
+	case resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests:
+		return permanentError{fmt.Errorf("loki returned %s: %s", resp.Status, bytes.TrimSpace(message))}
+	default:
+		return fmt.Errorf("loki returned %s: %s", resp.Status, bytes.TrimSpace(message))
+	}
+}
+
+// labelSetKey identifies a stream by its labels sorted by name
+func labelSetKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteString(name + "=" + strconv.Quote(labels[name]) + ",")
+	}
+	return sb.String()
+}
+
+// writeLogfmt appends key=value, quoting values that need it and skipping
+// empty ones
+func writeLogfmt(sb *strings.Builder, key, value string) {
+	if value == "" {
+		return
+	}
+	if sb.Len() > 0 {
+		sb.WriteString(" ")
+	}
+	if strings.ContainsAny(value, " =\"\\") {
+		value = strconv.Quote(value)
+	}
+	sb.WriteString(key + "=" + value)
+}
+
+// logSeverity guesses the severity of a line printed by the agent
+func logSeverity(line string) string {
+	switch {
+	case strings.HasPrefix(line, "Error"):
+		return events.SeverityError
+	case strings.Contains(line, "Spike"), strings.HasPrefix(line, "Dropping"), strings.Contains(line, "buffer full"):
+		return events.SeverityWarning
+	default:
+		return events.SeverityInfo
+	}
+}
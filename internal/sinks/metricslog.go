@@ -0,0 +1,328 @@
+package sinks
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sys-monitor-report/internal/history"
+	"sys-monitor-report/internal/utils"
+	"time"
+)
+
+const (
+	defaultMetricsLogSizeMB    = 100
+	defaultMetricsLogAgeHours  = 24
+	defaultMetricsLogRetention = 7
+	rotatedTimeLayout          = "20060102T150405.000Z"
+	// rotatedParseLayout reads rotated names. time.Parse accepts the
+	// milliseconds the layout lacks, so names without them parse too.
+	rotatedParseLayout = "20060102T150405Z"
+)
+
+// MetricsLog appends one JSON object per collection cycle to a file, e.g.
+// {"time":"...","host":"web-1","metrics":{"cpu_overall_usage":[{"value":12.5}]}}.
+// The file is rotated by size and age into gzipped files named after the
+// rotation time, e.g. metrics-20260101T000000.000Z.jsonl.gz.
+type MetricsLog struct {
+	path      string
+	host      string
+	maxBytes  int64
+	maxAge    time.Duration
+	retention time.Duration
+	maxFiles  int
+
+	// Only used from the batcher goroutine
+	file    *os.File
+	size    int64
+	started time.Time // Time of the first record in the current file
+
+	batcher *batcher
+}
+
+// metricsLogRecord is one line of the metrics log
+type metricsLogRecord struct {
+	Time    time.Time                    `json:"time"`
+	Host    string                       `json:"host"`
+	Metrics map[string][]metricsLogValue `json:"metrics"`
+}
+
+type metricsLogValue struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value"`
+}
+
+// NewMetricsLog opens or creates the log file and removes expired rotated files
+func NewMetricsLog(cfg utils.MetricsLogConfig, dataDir string) (*MetricsLog, error) {
+	if cfg.Path == "" {
+		cfg.Path = filepath.Join(dataDir, "metrics.jsonl")
+	}
+	if cfg.MaxSizeMB <= 0 {
+		cfg.MaxSizeMB = defaultMetricsLogSizeMB
+	}
+	if cfg.MaxAgeHours <= 0 {
+		cfg.MaxAgeHours = defaultMetricsLogAgeHours
+	}
+	if cfg.RetentionDays <= 0 {
+		cfg.RetentionDays = defaultMetricsLogRetention
+	}
+
+	m := &MetricsLog{
+		path:      cfg.Path,
+		host:      hostname(),
+		maxBytes:  int64(cfg.MaxSizeMB) << 20,
+		maxAge:    time.Duration(cfg.MaxAgeHours) * time.Hour,
+		retention: time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+		maxFiles:  cfg.MaxFiles,
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
+		return nil, fmt.Errorf("metrics_log: error creating directory: %v", err)
+	}
+	if err := m.open(); err != nil {
+		return nil, fmt.Errorf("metrics_log: %v", err)
+	}
+	if err := m.prune(time.Now()); err != nil {
+		fmt.Printf("Error cleaning up metrics log: %v\n", err)
+	}
+
+	m.batcher = newBatcher("metrics_log", 1000, 100, time.Second, 0, m.send)
+
+	return m, nil
+}
+
+// Write queues the snapshot as one JSON line
+func (m *MetricsLog) Write(snap history.Snapshot) {
+	record := metricsLogRecord{
+		Time:    snap.Time,
+		Host:    m.host,
+		Metrics: make(map[string][]metricsLogValue),
+	}
+	for _, sample := range snap.Samples {
+		record.Metrics[sample.Name] = append(record.Metrics[sample.Name], metricsLogValue{
+			Labels: sample.Labels,
+			Value:  sample.Value,
+		})
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		fmt.Printf("Error encoding metrics log record: %v\n", err)
+		return
+	}
+
+	m.batcher.add(line)
+}
+
+// Close writes buffered records and closes the file
+func (m *MetricsLog) Close() error {
+	m.batcher.close()
+	if m.file == nil {
+		return nil
+	}
+	return m.file.Close()
+}
+
+func (m *MetricsLog) send(_ context.Context, lines [][]byte) error {
+	for _, line := range lines {
+		now := time.Now()
+		if m.file != nil && m.size > 0 &&
+			(m.size+int64(len(line))+1 > m.maxBytes || now.Sub(m.started) >= m.maxAge) {
+			if err := m.rotate(now); err != nil {
+				return err
+			}
+		}
+		if m.file == nil {
+			if err := m.open(); err != nil {
+				return err
+			}
+		}
+		if m.size == 0 {
+			m.started = now
+		}
+
+		n, err := m.file.Write(append(line, '\n'))
+		m.size += int64(n)
+		if err != nil {
+			return fmt.Errorf("error writing metrics log: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// open opens the current file for appending. The age of an existing file is
+// taken from its first record.
+func (m *MetricsLog) open() error {
+	file, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening metrics log: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("error opening metrics log: %v", err)
+	}
+
+	m.file = file
+	m.size = info.Size()
+	m.started = info.ModTime()
+	if m.size > 0 {
+		if first, err := firstRecordTime(m.path); err == nil {
+			m.started = first
+		}
+	}
+
+	return nil
+}
+
+// rotate renames the current file after the rotation time, compresses it
+// and applies the retention limits. If a rotated file of that time already
+// exists, the time is moved forward a millisecond at a time.
+func (m *MetricsLog) rotate(now time.Time) error {
+	if err := m.file.Close(); err != nil {
+		fmt.Printf("Error closing metrics log: %v\n", err)
+	}
+	m.file = nil
+	m.size = 0
+
+	ext := filepath.Ext(m.path)
+	var rotated string
+	for stamp := now.UTC(); ; stamp = stamp.Add(time.Millisecond) {
+		rotated = strings.TrimSuffix(m.path, ext) + "-" + stamp.Format(rotatedTimeLayout) + ext
+		if !fileExists(rotated) && !fileExists(rotated+".gz") {
+			break
+		}
+	}
+	if err := os.Rename(m.path, rotated); err != nil {
+		return fmt.Errorf("error rotating metrics log: %v", err)
+	}
+
+	if err := m.prune(now); err != nil {
+		fmt.Printf("Error cleaning up metrics log: %v\n", err)
+	}
+	return nil
+}
+
+// prune compresses rotated files left uncompressed and deletes those beyond
+// the retention period or file count
+func (m *MetricsLog) prune(now time.Time) error {
+	dir := filepath.Dir(m.path)
+	ext := filepath.Ext(m.path)
+	prefix := strings.TrimSuffix(filepath.Base(m.path), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("error reading metrics log directory: %v", err)
+	}
+
+	type rotatedFile struct {
+		path string
+		time time.Time
+	}
+	var files []rotatedFile
+
+	for _, entry := range entries {
+		name := entry.Name()
+		stamp, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		compressed := strings.HasSuffix(stamp, ext+".gz")
+		stamp = strings.TrimSuffix(strings.TrimSuffix(stamp, ".gz"), ext)
+
+		rotatedAt, err := time.Parse(rotatedParseLayout, stamp)
+		if err != nil {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		if !compressed {
+			if err := gzipFile(path); err != nil {
+				return err
+			}
+			path += ".gz"
+		}
+		files = append(files, rotatedFile{path: path, time: rotatedAt})
+	}
+
+	// Newest first
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].time.After(files[j].time)
+	})
+
+	for i, file := range files {
+		expired := now.Sub(file.time) > m.retention
+		if expired || (m.maxFiles > 0 && i >= m.maxFiles) {
+			if err := os.Remove(file.path); err != nil {
+				return fmt.Errorf("error removing rotated metrics log: %v", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// fileExists reports whether anything exists at path
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// gzipFile replaces a file with a gzipped copy named <path>.gz
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening rotated metrics log: %v", err)
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path + ".gz")
+	if err != nil {
+		return fmt.Errorf("error creating compressed metrics log: %v", err)
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		return fmt.Errorf("error compressing metrics log: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		return fmt.Errorf("error compressing metrics log: %v", err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("error compressing metrics log: %v", err)
+	}
+
+	return os.Remove(path)
+}
+
+func firstRecordTime(path string) (time.Time, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer file.Close()
+
+	line, err := bufio.NewReader(file).ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return time.Time{}, err
+	}
+
+	var record struct {
+		Time time.Time `json:"time"`
+	}
+	if err := json.Unmarshal(line, &record); err != nil {
+		return time.Time{}, err
+	}
+	return record.Time, nil
+}
@@ -0,0 +1,449 @@
+package sinks
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sys-monitor-report/internal/history"
+	"sys-monitor-report/internal/utils"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	_ "google.golang.org/grpc/encoding/gzip" // Registers the gzip compressor
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	otlpExportMethod = "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export"
+	otlpScopeName    = "sys-monitor-report"
+	otlpSchemaURL    = "https://opentelemetry.io/schemas/1.26.0"
+)
+
+// OTLP exports snapshots as OpenTelemetry metrics over OTLP/HTTP (protobuf)
+// or OTLP/gRPC. Series are renamed to the OTel system semantic conventions
+// where one exists and exported as sysmon.<name> otherwise.
+type OTLP struct {
+	cfg      utils.OTLPConfig
+	resource []byte // Encoded Resource message
+	start    uint64 // Start time of cumulative sums, Unix nanoseconds
+
+	client  *http.Client
+	conn    *grpc.ClientConn
+	batcher *batcher
+}
+
+// NewOTLP connects to the collector and starts the batcher
+func NewOTLP(cfg utils.OTLPConfig) (*OTLP, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("otlp endpoint is required")
+	}
+	if cfg.Protocol == "" {
+		cfg.Protocol = "http/protobuf"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10
+	}
+
+	attributes := map[string]string{
+		"service.name": "sys-monitor-report",
+		"host.name":    hostname(),
+		"host.arch":    runtime.GOARCH,
+		"os.type":      runtime.GOOS,
+	}
+	for name, value := range cfg.ResourceAttributes {
+		attributes[name] = value
+	}
+
+	o := &OTLP{
+		cfg:      cfg,
+		resource: encodeResource(attributes),
+		start:    uint64(time.Now().UnixNano()),
+	}
+	send := o.sendHTTP
+
+	switch cfg.Protocol {
+	case "http/protobuf":
+		o.cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+		if !strings.HasSuffix(o.cfg.Endpoint, "/v1/metrics") {
+			o.cfg.Endpoint += "/v1/metrics"
+		}
+		o.client = &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second}
+	case "grpc":
+		creds := credentials.NewTLS(&tls.Config{})
+		if cfg.Insecure {
+			creds = insecure.NewCredentials()
+		}
+		conn, err := grpc.NewClient(cfg.Endpoint, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			return nil, fmt.Errorf("error creating otlp gRPC client: %v", err)
+		}
+		o.conn = conn
+		send = o.sendGRPC
+	default:
+		return nil, fmt.Errorf("unsupported otlp protocol: %s", cfg.Protocol)
+	}
+
+	o.batcher = newBatcher("otlp", 100, 1, 10*time.Second, 3, send)
+
+	return o, nil
+}
+
+// Write encodes a snapshot as one ResourceMetrics and queues it
+func (o *OTLP) Write(snap history.Snapshot) {
+	o.batcher.add(o.encodeResourceMetrics(snap))
+}
+
+// Close flushes queued snapshots and closes the gRPC connection
+func (o *OTLP) Close() error {
+	o.batcher.close()
+	if o.conn != nil {
+		return o.conn.Close()
+	}
+	return nil
+}
+
+func (o *OTLP) sendHTTP(ctx context.Context, items [][]byte) error {
+	// Encoded resource_metrics fields concatenate into one request
+	payload := bytes.Join(items, nil)
+
+	var body bytes.Buffer
+	if o.cfg.Gzip {
+		gz := gzip.NewWriter(&body)
+		gz.Write(payload)
+		if err := gz.Close(); err != nil {
+			return permanentError{err}
+		}
+	} else {
+		body.Write(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.cfg.Endpoint, &body)
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	if o.cfg.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for key, value := range o.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted:
+		return nil
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return fmt.Errorf("otlp endpoint returned %s", resp.Status)
+	default:
+		return permanentError{fmt.Errorf("otlp endpoint returned %s", resp.Status)}
+	}
+}
+
+func (o *OTLP) sendGRPC(ctx context.Context, items [][]byte) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(o.cfg.Timeout)*time.Second)
+	defer cancel()
+
+	if len(o.cfg.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(o.cfg.Headers))
+	}
+
+	options := []grpc.CallOption{grpc.ForceCodec(rawCodec{})}
+	if o.cfg.Gzip {
+		options = append(options, grpc.UseCompressor("gzip"))
+	}
+
+	var response []byte
+	err := o.conn.Invoke(ctx, otlpExportMethod, bytes.Join(items, nil), &response, options...)
+	switch status.Code(err) {
+	case codes.OK:
+		return nil
+	case codes.Canceled, codes.DeadlineExceeded, codes.ResourceExhausted,
+		codes.Aborted, codes.OutOfRange, codes.Unavailable, codes.DataLoss:
+		return err
+	default:
+		return permanentError{err}
+	}
+}
+
+// rawCodec passes pre-encoded protobuf messages through gRPC unchanged
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("raw codec cannot marshal %T", v)
+	}
+	return b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("raw codec cannot unmarshal into %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string { return "proto" }
+
+// otelMetric describes the OTel metric a series is exported as
+type otelMetric struct {
+	name string
+	unit string
+	sum  bool // Monotonic cumulative sum instead of a gauge
+}
+
+type otelAttribute struct {
+	key      string
+	value    string
+	intValue bool // Encoded as int_value instead of string_value
+}
+
+// mapSample converts a series to its OTel semantic convention equivalent
+func mapSample(sample history.Sample) (otelMetric, []otelAttribute, float64) {
+	value := sample.Value
+	labels := sample.Labels
+
+	switch sample.Name {
+	case "cpu_usage_percentage":
+		// Only the cores map to system.cpu.utilization. cpu_overall_usage
+		// stays sysmon.cpu_overall_usage, as a point without
+		// cpu.logical_number would be counted along with the cores.
+		core, err := strconv.Atoi(strings.TrimPrefix(labels["core"], "core_"))
+		if err == nil {
+			return otelMetric{name: "system.cpu.utilization", unit: "1"},
+				[]otelAttribute{{key: "cpu.logical_number", value: strconv.Itoa(core - 1), intValue: true}},
+				value / 100
+		}
+	case "virtual_memory_usage":
+		switch labels["type"] {
+		case "used_mb", "free_mb":
+			state := strings.TrimSuffix(labels["type"], "_mb")
+			return otelMetric{name: "system.memory.usage", unit: "By"},
+				[]otelAttribute{{key: "system.memory.state", value: state}}, value * 1e6
+		case "total_mb":
+			return otelMetric{name: "system.memory.limit", unit: "By"}, nil, value * 1e6
+		case "used_percent":
+			return otelMetric{name: "system.memory.utilization", unit: "1"},
+				[]otelAttribute{{key: "system.memory.state", value: "used"}}, value / 100
+		}
+	case "swap_memory_usage":
+		switch labels["type"] {
+		case "used_mb", "free_mb":
+			state := strings.TrimSuffix(labels["type"], "_mb")
+			return otelMetric{name: "system.paging.usage", unit: "By"},
+				[]otelAttribute{{key: "system.paging.state", value: state}}, value * 1e6
+		case "used_percent":
+			return otelMetric{name: "system.paging.utilization", unit: "1"},
+				[]otelAttribute{{key: "system.paging.state", value: "used"}}, value / 100
+		}
+	case "partition_space":
+		device := otelAttribute{key: "system.device", value: labels["device"]}
+		switch labels["type"] {
+		case "used_gb", "free_gb":
+			state := strings.TrimSuffix(labels["type"], "_gb")
+			return otelMetric{name: "system.filesystem.usage", unit: "By"},
+				[]otelAttribute{device, {key: "system.filesystem.state", value: state}}, value * 1e9
+		case "total_gb":
+			return otelMetric{name: "system.filesystem.limit", unit: "By"},
+				[]otelAttribute{device}, value * 1e9
+		case "used_percent":
+			return otelMetric{name: "system.filesystem.utilization", unit: "1"},
+				[]otelAttribute{device}, value / 100
+		}
+	case "process_cpu_usage", "process_memory_usage":
+		name := "process.cpu.utilization"
+		if sample.Name == "process_memory_usage" {
+			name = "process.memory.utilization"
+		}
+		return otelMetric{name: name, unit: "1"}, processAttributes(labels), value / 100
+	case "process_io_read_count", "process_io_write_count":
+		direction := "read"
+		if sample.Name == "process_io_write_count" {
+			direction = "write"
+		}
+		return otelMetric{name: "process.disk.operations", unit: "{operation}", sum: true},
+			append(processAttributes(labels), otelAttribute{key: "disk.io.direction", value: direction}),
+			value
+	}
+
+	// No convention: keep the Prometheus name and labels
+	var attributes []otelAttribute
+	for key, value := range labels {
+		attributes = append(attributes, otelAttribute{key: key, value: value})
+	}
+	return otelMetric{name: "sysmon." + sample.Name, sum: sample.Type == "counter"}, attributes, value
+}
+
+func processAttributes(labels map[string]string) []otelAttribute {
+	return []otelAttribute{
+		{key: "process.pid", value: labels["pid"], intValue: true},
+		{key: "process.executable.name", value: labels["name"]},
+	}
+}
+
+// encodeResourceMetrics builds a ResourceMetrics message wrapped as field 1
+// of ExportMetricsServiceRequest:
+//
+//	message ResourceMetrics { Resource resource = 1; repeated ScopeMetrics scope_metrics = 2; string schema_url = 3; }
+//	message ScopeMetrics    { InstrumentationScope scope = 1; repeated Metric metrics = 2; string schema_url = 3; }
+//	message Metric          { string name = 1; string unit = 3; Gauge gauge = 5; Sum sum = 7; }
+//	message Gauge           { repeated NumberDataPoint data_points = 1; }
+//	message Sum             { repeated NumberDataPoint data_points = 1; AggregationTemporality aggregation_temporality = 2; bool is_monotonic = 3; }
+//	message NumberDataPoint { fixed64 start_time_unix_nano = 2; fixed64 time_unix_nano = 3; double as_double = 4; repeated KeyValue attributes = 7; }
+func (o *OTLP) encodeResourceMetrics(snap history.Snapshot) []byte {
+	type metric struct {
+		otelMetric
+		points [][]byte
+	}
+
+	metrics := make(map[string]*metric)
+	timestamp := uint64(snap.Time.UnixNano())
+
+	for _, sample := range snap.Samples {
+		descriptor, attributes, value := mapSample(sample)
+
+		m, exists := metrics[descriptor.name]
+		if !exists {
+			m = &metric{otelMetric: descriptor}
+			metrics[descriptor.name] = m
+		}
+
+		var point []byte
+		if m.sum {
+			point = protowire.AppendTag(point, 2, protowire.Fixed64Type)
+			point = protowire.AppendFixed64(point, o.start)
+		}
+		point = protowire.AppendTag(point, 3, protowire.Fixed64Type)
+		point = protowire.AppendFixed64(point, timestamp)
+		point = protowire.AppendTag(point, 4, protowire.Fixed64Type)
+		point = protowire.AppendFixed64(point, math.Float64bits(value))
+		for _, attribute := range attributes {
+			point = protowire.AppendTag(point, 7, protowire.BytesType)
+			point = protowire.AppendBytes(point, encodeKeyValue(attribute))
+		}
+
+		m.points = append(m.points, point)
+	}
+
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var scope []byte
+	var scopeInfo []byte
+	scopeInfo = protowire.AppendTag(scopeInfo, 1, protowire.BytesType)
+	scopeInfo = protowire.AppendString(scopeInfo, otlpScopeName)
+	scope = protowire.AppendTag(scope, 1, protowire.BytesType)
+	scope = protowire.AppendBytes(scope, scopeInfo)
+
+	for _, name := range names {
+		m := metrics[name]
+
+		var data []byte
+		for _, point := range m.points {
+			data = protowire.AppendTag(data, 1, protowire.BytesType)
+			data = protowire.AppendBytes(data, point)
+		}
+
+		var encoded []byte
+		encoded = protowire.AppendTag(encoded, 1, protowire.BytesType)
+		encoded = protowire.AppendString(encoded, m.name)
+		if m.unit != "" {
+			encoded = protowire.AppendTag(encoded, 3, protowire.BytesType)
+			encoded = protowire.AppendString(encoded, m.unit)
+		}
+		if m.sum {
+			// AGGREGATION_TEMPORALITY_CUMULATIVE = 2, monotonic
+			data = protowire.AppendTag(data, 2, protowire.VarintType)
+			data = protowire.AppendVarint(data, 2)
+			data = protowire.AppendTag(data, 3, protowire.VarintType)
+			data = protowire.AppendVarint(data, 1)
+			encoded = protowire.AppendTag(encoded, 7, protowire.BytesType)
+		} else {
+			encoded = protowire.AppendTag(encoded, 5, protowire.BytesType)
+		}
+		encoded = protowire.AppendBytes(encoded, data)
+
+		scope = protowire.AppendTag(scope, 2, protowire.BytesType)
+		scope = protowire.AppendBytes(scope, encoded)
+	}
+	scope = protowire.AppendTag(scope, 3, protowire.BytesType)
+	scope = protowire.AppendString(scope, otlpSchemaURL)
+
+	var resourceMetrics []byte
+	resourceMetrics = protowire.AppendTag(resourceMetrics, 1, protowire.BytesType)
+	resourceMetrics = protowire.AppendBytes(resourceMetrics, o.resource)
+	resourceMetrics = protowire.AppendTag(resourceMetrics, 2, protowire.BytesType)
+	resourceMetrics = protowire.AppendBytes(resourceMetrics, scope)
+	resourceMetrics = protowire.AppendTag(resourceMetrics, 3, protowire.BytesType)
+	resourceMetrics = protowire.AppendString(resourceMetrics, otlpSchemaURL)
+
+	var out []byte
+	out = protowire.AppendTag(out, 1, protowire.BytesType)
+	out = protowire.AppendBytes(out, resourceMetrics)
+	return out
+}
+
+// encodeResource builds a Resource message: repeated KeyValue attributes = 1
+func encodeResource(attributes map[string]string) []byte {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var resource []byte
+	for _, key := range keys {
+		resource = protowire.AppendTag(resource, 1, protowire.BytesType)
+		resource = protowire.AppendBytes(resource, encodeKeyValue(otelAttribute{key: key, value: attributes[key]}))
+	}
+	return resource
+}
+
+// encodeKeyValue builds a KeyValue message:
+//
+//	message KeyValue { string key = 1; AnyValue value = 2; }
+//	message AnyValue { string string_value = 1; int64 int_value = 3; }
+func encodeKeyValue(attribute otelAttribute) []byte {
+	var value []byte
+	if n, err := strconv.ParseInt(attribute.value, 10, 64); attribute.intValue && err == nil {
+		value = protowire.AppendTag(value, 3, protowire.VarintType)
+		value = protowire.AppendVarint(value, uint64(n))
+	} else {
+		value = protowire.AppendTag(value, 1, protowire.BytesType)
+		value = protowire.AppendString(value, attribute.value)
+	}
+
+	var kv []byte
+	kv = protowire.AppendTag(kv, 1, protowire.BytesType)
+	kv = protowire.AppendString(kv, attribute.key)
+	kv = protowire.AppendTag(kv, 2, protowire.BytesType)
+	kv = protowire.AppendBytes(kv, value)
+	return kv
+}
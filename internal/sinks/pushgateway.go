@@ -0,0 +1,93 @@
+package sinks
+
+import (
+	"fmt"
+	"net/http"
+	"sys-monitor-report/internal/history"
+	"sys-monitor-report/internal/report"
+	"sys-monitor-report/internal/utils"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// Pushgateway replaces the agent's group on a Prometheus Pushgateway after
+// every collection cycle and deletes it on graceful shutdown, so metrics of
+// short-lived hosts do not linger in the gateway.
+type Pushgateway struct {
+	pusher  *push.Pusher
+	trigger chan struct{}
+	done    chan struct{}
+}
+
+// NewPushgateway configures the job and grouping labels and starts the pusher
+func NewPushgateway(cfg utils.PushgatewayConfig) (*Pushgateway, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("pushgateway url is required")
+	}
+	if cfg.Job == "" {
+		cfg.Job = "sys-monitor"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10
+	}
+
+	grouping := map[string]string{"instance": hostname()}
+	for name, value := range cfg.Grouping {
+		grouping[name] = value
+	}
+
+	pusher := push.New(cfg.URL, cfg.Job).
+		Gatherer(report.Registry).
+		Client(&http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second})
+	for name, value := range grouping {
+		pusher = pusher.Grouping(name, value)
+	}
+	if cfg.Username != "" {
+		pusher = pusher.BasicAuth(cfg.Username, cfg.Password)
+	}
+	if err := pusher.Error(); err != nil {
+		return nil, err
+	}
+
+	pg := &Pushgateway{
+		pusher:  pusher,
+		trigger: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	go pg.run()
+
+	return pg, nil
+}
+
+// Write schedules a push of the current registry. A push still in flight
+// absorbs further cycles; the next one sends the latest values.
+func (pg *Pushgateway) Write(history.Snapshot) {
+	select {
+	case pg.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Close waits for the last push and deletes the group from the gateway
+func (pg *Pushgateway) Close() error {
+	close(pg.trigger)
+	<-pg.done
+
+	if err := pg.pusher.Delete(); err != nil {
+		return fmt.Errorf("error deleting pushgateway group: %v", err)
+	}
+	return nil
+}
+
+func (pg *Pushgateway) run() {
+	defer close(pg.done)
+
+	for range pg.trigger {
+		// PUT replaces the whole group, so series of exited processes vanish
+		if err := pg.pusher.Push(); err != nil {
+			fmt.Printf("Error pushing to pushgateway: %v\n", err)
+		}
+	}
+}
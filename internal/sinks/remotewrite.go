@@ -0,0 +1,278 @@
+package sinks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sys-monitor-report/internal/history"
+	"sys-monitor-report/internal/utils"
+	"sys-monitor-report/internal/wal"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// labelNamePattern matches valid Prometheus label names
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// RemoteWrite pushes snapshots with the Prometheus remote_write protocol.
+// Every snapshot is first appended to an on-disk WAL, so cycles collected
+// while the receiver is unreachable are replayed once it is back.
+type RemoteWrite struct {
+	cfg    utils.RemoteWriteConfig
+	labels map[string]string
+	wal    *wal.WAL
+	client *http.Client
+
+	notify chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRemoteWrite opens the WAL and starts the sender
+func NewRemoteWrite(cfg utils.RemoteWriteConfig, dataDir string) (*RemoteWrite, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("remote_write url is required")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 10
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 300
+	}
+	if cfg.WALMaxMB <= 0 {
+		cfg.WALMaxMB = 256
+	}
+
+	log, err := wal.Open(filepath.Join(dataDir, "remote_write"), int64(cfg.WALMaxMB)<<20)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{
+		"job":      "sys-monitor",
+		"instance": hostname(),
+	}
+	for name, value := range cfg.ExternalLabels {
+		if !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+			return nil, fmt.Errorf("invalid external label name: %q", name)
+		}
+		labels[name] = value
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rw := &RemoteWrite{
+		cfg:    cfg,
+		labels: labels,
+		wal:    log,
+		client: &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+		notify: make(chan struct{}, 1),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go rw.run(ctx)
+
+	return rw, nil
+}
+
+// Write queues a snapshot for delivery
+func (rw *RemoteWrite) Write(snap history.Snapshot) {
+	data, err := encodeTimeSeries(snap, rw.labels)
+	if err != nil {
+		fmt.Printf("Error encoding remote_write data: %v\n", err)
+	}
+	if err := rw.wal.Append(data); err != nil {
+		fmt.Printf("Error queueing remote_write data: %v\n", err)
+		return
+	}
+
+	select {
+	case rw.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops the sender. Undelivered data stays in the WAL for the next start.
+func (rw *RemoteWrite) Close() error {
+	rw.cancel()
+	<-rw.done
+	return rw.wal.Close()
+}
+
+// run sends batches from the WAL, retrying with exponential backoff
+func (rw *RemoteWrite) run(ctx context.Context) {
+	defer close(rw.done)
+
+	backoff := time.Second
+	maxBackoff := time.Duration(rw.cfg.MaxBackoff) * time.Second
+
+	for {
+		records, pos, err := rw.wal.Read(rw.cfg.BatchSize)
+		if err != nil {
+			fmt.Printf("Error reading remote_write WAL: %v\n", err)
+		}
+
+		if len(records) == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-rw.notify:
+				continue
+			}
+		}
+
+		// Encoded TimeSeries fields concatenate into a valid WriteRequest
+		err = rw.send(ctx, bytes.Join(records, nil))
+		if err == nil {
+			backoff = time.Second
+			if err := rw.wal.Commit(pos); err != nil {
+				fmt.Printf("Error committing remote_write WAL: %v\n", err)
+			}
+			continue
+		}
+
+		if _, permanent := err.(permanentError); permanent {
+			fmt.Printf("Dropping remote_write batch: %v\n", err)
+			if err := rw.wal.Commit(pos); err != nil {
+				fmt.Printf("Error committing remote_write WAL: %v\n", err)
+			}
+			continue
+		}
+
+		fmt.Printf("Error sending remote_write batch, retrying in %s: %v\n", backoff, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// permanentError is a rejection that retrying will not fix
+type permanentError struct{ error }
+
+func (rw *RemoteWrite) send(ctx context.Context, writeRequest []byte) error {
+	body := snappy.Encode(nil, writeRequest)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rw.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", "sys-monitor-report")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for key, value := range rw.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+	if rw.cfg.Username != "" {
+		req.SetBasicAuth(rw.cfg.Username, rw.cfg.Password)
+	}
+	if rw.cfg.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+rw.cfg.BearerToken)
+	}
+
+	resp, err := rw.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	switch {
+	case resp.StatusCode/100 == 2:
+		return nil
+	case resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests:
+		return permanentError{fmt.Errorf("server returned %s: %s", resp.Status, bytes.TrimSpace(message))}
+	default:
+		return fmt.Errorf("server returned %s: %s", resp.Status, bytes.TrimSpace(message))
+	}
+}
+
+// encodeTimeSeries encodes the samples of a snapshot as repeated
+// WriteRequest.timeseries fields:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label        { string name = 1; string value = 2; }
+//	message Sample       { double value = 1; int64 timestamp = 2; }
+//
+// External labels are only attached to series that lack a label of the same
+// name, as Prometheus does, so a series' own job or instance label wins.
+// Series with an invalid or reserved label name, which receivers reject, are
+// left out and reported in the error.
+func encodeTimeSeries(snap history.Snapshot, externalLabels map[string]string) ([]byte, error) {
+	timestamp := snap.Time.UnixMilli()
+	var out []byte
+	var errs []error
+
+	for _, sample := range snap.Samples {
+		type label struct{ name, value string }
+
+		labels := make([]label, 0, len(sample.Labels)+len(externalLabels)+1)
+		labels = append(labels, label{"__name__", sample.Name})
+		own := map[string]bool{"__name__": true}
+		var invalid string
+		for name, value := range sample.Labels {
+			if !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+				invalid = name
+			}
+			labels = append(labels, label{name, value})
+			own[name] = true
+		}
+		if invalid != "" {
+			errs = append(errs, fmt.Errorf("series %s has invalid label name %q", sample.SeriesKey(), invalid))
+			continue
+		}
+		for name, value := range externalLabels {
+			if !own[name] {
+				labels = append(labels, label{name, value})
+			}
+		}
+
+		sort.Slice(labels, func(i, j int) bool {
+			return labels[i].name < labels[j].name
+		})
+
+		var series []byte
+		for _, l := range labels {
+			var label []byte
+			label = protowire.AppendTag(label, 1, protowire.BytesType)
+			label = protowire.AppendString(label, l.name)
+			label = protowire.AppendTag(label, 2, protowire.BytesType)
+			label = protowire.AppendString(label, l.value)
+
+			series = protowire.AppendTag(series, 1, protowire.BytesType)
+			series = protowire.AppendBytes(series, label)
+		}
+
+		var point []byte
+		point = protowire.AppendTag(point, 1, protowire.Fixed64Type)
+		point = protowire.AppendFixed64(point, math.Float64bits(sample.Value))
+		point = protowire.AppendTag(point, 2, protowire.VarintType)
+		point = protowire.AppendVarint(point, uint64(timestamp))
+
+		series = protowire.AppendTag(series, 2, protowire.BytesType)
+		series = protowire.AppendBytes(series, point)
+
+		out = protowire.AppendTag(out, 1, protowire.BytesType)
+		out = protowire.AppendBytes(out, series)
+	}
+
+	return out, errors.Join(errs...)
+}
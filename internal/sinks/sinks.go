@@ -0,0 +1,124 @@
+package sinks
+
+import (
+	"fmt"
+	"os"
+	"sys-monitor-report/internal/events"
+	"sys-monitor-report/internal/history"
+	"sys-monitor-report/internal/utils"
+)
+
+// Sink receives the snapshot of every collection cycle. Write must not block
+// the collection loop; sinks buffer and deliver in the background.
+type Sink interface {
+	Write(snap history.Snapshot)
+	Close() error
+}
+
+// EventSink receives spike, alert and collector failure events as they are
+// published. Like Write, Event must not block.
+type EventSink interface {
+	Event(e events.Event)
+	Close() error
+}
+
+// LogSink is an event sink that also receives the agent's own log lines
+type LogSink interface {
+	EventSink
+	Log(line string)
+}
+
+// Open creates the sinks enabled in the config
+func Open(config utils.Config) ([]Sink, error) {
+	var sinks []Sink
+
+	if config.RemoteWrite != nil {
+		sink, err := NewRemoteWrite(*config.RemoteWrite, config.DataDir)
+		if err != nil {
+			return nil, fmt.Errorf("error configuring remote_write: %v", err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if config.Pushgateway != nil {
+		sink, err := NewPushgateway(*config.Pushgateway)
+		if err != nil {
+			return nil, fmt.Errorf("error configuring pushgateway: %v", err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if config.InfluxDB != nil {
+		sink, err := NewInfluxDB(*config.InfluxDB)
+		if err != nil {
+			return nil, fmt.Errorf("error configuring influxdb: %v", err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if config.Graphite != nil {
+		sink, err := NewGraphite(*config.Graphite)
+		if err != nil {
+			return nil, fmt.Errorf("error configuring graphite: %v", err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if config.StatsD != nil {
+		sink, err := NewStatsD(*config.StatsD)
+		if err != nil {
+			return nil, fmt.Errorf("error configuring statsd: %v", err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if config.OTLP != nil {
+		sink, err := NewOTLP(*config.OTLP)
+		if err != nil {
+			return nil, fmt.Errorf("error configuring otlp: %v", err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if config.MetricsLog != nil {
+		sink, err := NewMetricsLog(*config.MetricsLog, config.DataDir)
+		if err != nil {
+			return nil, fmt.Errorf("error configuring metrics_log: %v", err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	return sinks, nil
+}
+
+// OpenEventSinks creates the event sinks enabled in the config
+func OpenEventSinks(config utils.Config) ([]EventSink, error) {
+	var sinks []EventSink
+
+	if config.Syslog != nil {
+		sink, err := NewSyslog(*config.Syslog)
+		if err != nil {
+			return nil, fmt.Errorf("error configuring syslog: %v", err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if config.Loki != nil {
+		sink, err := NewLoki(*config.Loki)
+		if err != nil {
+			return nil, fmt.Errorf("error configuring loki: %v", err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	return sinks, nil
+}
+
+// hostname returns the name used to identify this agent in pushed data
+func hostname() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return host
+}
@@ -0,0 +1,99 @@
+package sinks
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sys-monitor-report/internal/history"
+	"sys-monitor-report/internal/utils"
+)
+
+// StatsD emits gauges for gauge metrics and counter increments for counter
+// metrics, using the same dotted paths as the Graphite sink
+type StatsD struct {
+	prefix   string
+	conn     *reconnectingConn
+	batcher  *batcher
+	counters map[string]float64 // Last value of every counter series
+}
+
+// NewStatsD configures the path prefix and connection
+func NewStatsD(cfg utils.StatsDConfig) (*StatsD, error) {
+	if cfg.Protocol == "" {
+		cfg.Protocol = "udp"
+	}
+
+	conn, err := newReconnectingConn(cfg.Protocol, cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("statsd: %v", err)
+	}
+
+	s := &StatsD{
+		prefix:   pathPrefix(cfg.Prefix, cfg.Host),
+		conn:     conn,
+		counters: make(map[string]float64),
+	}
+	s.batcher = newBatcher(
+		"statsd",
+		defaultBufferLines,
+		defaultBufferLines/10,
+		defaultFlushPeriod,
+		0,
+		s.send,
+	)
+
+	return s, nil
+}
+
+// Write queues a gauge or counter line per sample
+func (s *StatsD) Write(snap history.Snapshot) {
+	lines := make([][]byte, 0, len(snap.Samples))
+	// Only series still present are remembered, so exited processes are forgotten
+	counters := make(map[string]float64)
+
+	for _, sample := range snap.Samples {
+		path := s.prefix + metricPath(sample)
+
+		if sample.Type == "counter" {
+			last, seen := s.counters[path]
+			counters[path] = sample.Value
+			if !seen {
+				continue
+			}
+
+			delta := sample.Value - last
+			if delta < 0 {
+				// Counter reset: everything since the reset is new
+				delta = sample.Value
+			}
+			if delta > 0 {
+				lines = append(lines, []byte(path+":"+formatStatsDValue(delta)+"|c"))
+			}
+			continue
+		}
+
+		// A leading sign means a relative change, so negative gauges are
+		// set by zeroing first
+		if sample.Value < 0 {
+			lines = append(lines, []byte(path+":0|g"))
+		}
+		lines = append(lines, []byte(path+":"+formatStatsDValue(sample.Value)+"|g"))
+	}
+
+	s.counters = counters
+	s.batcher.add(lines...)
+}
+
+// Close flushes buffered lines and closes the connection
+func (s *StatsD) Close() error {
+	s.batcher.close()
+	return s.conn.close()
+}
+
+func (s *StatsD) send(_ context.Context, lines [][]byte) error {
+	return s.conn.writeLines(lines, udpPayloadSize)
+}
+
+func formatStatsDValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
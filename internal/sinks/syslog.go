@@ -0,0 +1,181 @@
+package sinks
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sys-monitor-report/internal/events"
+	"sys-monitor-report/internal/utils"
+	"time"
+)
+
+const (
+	defaultSyslogSocket  = "/dev/log"
+	defaultSyslogAppName = "sys-monitor"
+	defaultSyslogBuffer  = 10000
+
+	// syslogTimeLayout is RFC 3339 with microseconds, as RFC 5424 allows at
+	// most six fractional digits
+	syslogTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+	// syslogSDID names the structured data element. 32473 is the private
+	// enterprise number reserved for documentation (RFC 5612).
+	syslogSDID = "sysmon@32473"
+)
+
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSeverities maps event severities to syslog severity codes
+var syslogSeverities = map[string]int{
+	events.SeverityError:   3,
+	events.SeverityWarning: 4,
+	events.SeverityInfo:    6,
+}
+
+// Syslog forwards events as RFC 5424 messages over UDP, TCP, TLS or the
+// local syslog socket, e.g.
+// <164>1 2026-01-01T12:00:00.000000Z web-1 sys-monitor 812 alert [sysmon@32473 collector="cpu" ...] cpu alert firing: ...
+type Syslog struct {
+	facility int
+	host     string
+	appName  string
+	procID   string
+	stream   bool // Octet-counting framing (RFC 6587) over TCP and TLS
+
+	conn    *reconnectingConn
+	batcher *batcher
+}
+
+// NewSyslog configures the transport and message header
+func NewSyslog(cfg utils.SyslogConfig) (*Syslog, error) {
+	if cfg.Protocol == "" {
+		cfg.Protocol = "udp"
+		if cfg.Address == "" {
+			cfg.Protocol = "unixgram"
+		}
+	}
+	if cfg.Protocol == "unixgram" && cfg.Address == "" {
+		cfg.Address = defaultSyslogSocket
+	}
+	if cfg.Facility == "" {
+		cfg.Facility = "local0"
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = defaultSyslogAppName
+	}
+
+	facility, ok := syslogFacilities[cfg.Facility]
+	if !ok {
+		return nil, fmt.Errorf("syslog: unknown facility: %s", cfg.Facility)
+	}
+
+	conn, err := newReconnectingConn(cfg.Protocol, cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("syslog: %v", err)
+	}
+	if cfg.Protocol == "tls" {
+		conn.tlsConfig = &tls.Config{}
+		if cfg.CAFile != "" {
+			pem, err := os.ReadFile(cfg.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("syslog: error reading CA file: %v", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("syslog: no certificates found in %s", cfg.CAFile)
+			}
+			conn.tlsConfig.RootCAs = pool
+		}
+	}
+
+	s := &Syslog{
+		facility: facility,
+		host:     syslogName(hostname(), 255),
+		appName:  syslogName(cfg.AppName, 48),
+		procID:   strconv.Itoa(os.Getpid()),
+		stream:   cfg.Protocol == "tcp" || cfg.Protocol == "tls",
+		conn:     conn,
+	}
+	s.batcher = newBatcher("syslog", defaultSyslogBuffer, 100, time.Second, 0, s.send)
+
+	return s, nil
+}
+
+// Event queues one message for the event
+func (s *Syslog) Event(e events.Event) {
+	severity, ok := syslogSeverities[e.Severity]
+	if !ok {
+		severity = syslogSeverities[events.SeverityInfo]
+	}
+
+	var sd strings.Builder
+	sd.WriteString("[" + syslogSDID)
+	writeSDParam(&sd, "collector", e.Collector)
+	writeSDParam(&sd, "metric", e.Metric)
+	if e.Kind != events.KindCollectorError {
+		writeSDParam(&sd, "value", strconv.FormatFloat(e.Value, 'f', -1, 64))
+		writeSDParam(&sd, "threshold", strconv.FormatFloat(e.Threshold, 'f', -1, 64))
+	}
+	writeSDParam(&sd, "state", e.State)
+	sd.WriteString("]")
+
+	msg := fmt.Sprintf("<%d>1 %s %s %s %s %s %s %s",
+		s.facility*8+severity,
+		e.Time.UTC().Format(syslogTimeLayout),
+		s.host,
+		s.appName,
+		s.procID,
+		e.Kind,
+		sd.String(),
+		e.Message,
+	)
+
+	if s.stream {
+		msg = strconv.Itoa(len(msg)) + " " + msg
+	}
+	s.batcher.add([]byte(msg))
+}
+
+// Close sends buffered messages and closes the connection
+func (s *Syslog) Close() error {
+	s.batcher.close()
+	return s.conn.close()
+}
+
+func (s *Syslog) send(_ context.Context, msgs [][]byte) error {
+	return s.conn.writeFrames(msgs)
+}
+
+// writeSDParam appends a PARAM-NAME="value" pair, skipping empty values
+func writeSDParam(sd *strings.Builder, name, value string) {
+	if value == "" {
+		return
+	}
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+	sd.WriteString(" " + name + `="` + value + `"`)
+}
+
+// syslogName makes a header field printable ASCII without spaces
+func syslogName(value string, maxLen int) string {
+	name := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, value)
+	if name == "" {
+		return "-"
+	}
+	if len(name) > maxLen {
+		name = name[:maxLen]
+	}
+	return name
+}
@@ -9,16 +9,228 @@ import (
 type Config struct {
 	LogInterval         int `yaml:"log_interval"`
 	LogIntervalHighFreq int `yaml:"log_interval_high_freq"`
-	Thresholds          struct {
-		CPU     int `yaml:"cpu"`
-		Memory  int `yaml:"memory"`
-		Disk    int `yaml:"disk"`
-		Network int `yaml:"network"`
-	}
+	Thresholds          ThresholdsConfig
+	DataDir             string           `yaml:"data_dir"` // Directory for history and report run logs
+	History             HistoryConfig    `yaml:"history"`
+	Reports             []ReportConfig   `yaml:"reports"`
+	Partitions          PartitionsConfig `yaml:"partitions"`
+	Listeners           ListenersConfig  `yaml:"listeners"`
+
+	RemoteWrite *RemoteWriteConfig `yaml:"remote_write"`
+	Pushgateway *PushgatewayConfig `yaml:"pushgateway"`
+	InfluxDB    *InfluxDBConfig    `yaml:"influxdb"`
+	Graphite    *GraphiteConfig    `yaml:"graphite"`
+	StatsD      *StatsDConfig      `yaml:"statsd"`
+	OTLP        *OTLPConfig        `yaml:"otlp"`
+	MetricsLog  *MetricsLogConfig  `yaml:"metrics_log"`
+	Syslog      *SyslogConfig      `yaml:"syslog"`
+	Loki        *LokiConfig        `yaml:"loki"`
+}
+
+// ThresholdsConfig holds the spike detection thresholds
+type ThresholdsConfig struct {
+	CPU     int     `yaml:"cpu"`
+	Memory  int     `yaml:"memory"` // RAM and swap combined (%)
+	Disk    int     `yaml:"disk"`
+	Network int     `yaml:"network"`
+	Load    float64 `yaml:"load"`  // 1-minute load average per core
+	Steal   int     `yaml:"steal"` // CPU time stolen by the hypervisor (%)
+
+	Temperature int `yaml:"temperature"` // Hottest sensor (°C)
+
+	// Alerts when a partition is predicted to be full within this many hours
+	DiskFullHours int `yaml:"disk_full_hours"`
+
+	// Alerts when available RAM falls below this share (%)
+	MemoryAvailable int `yaml:"memory_available"`
+
+	// Share of the last minute in which some tasks stalled waiting for memory or IO (%)
+	MemoryPressure int `yaml:"memory_pressure"`
+	IOPressure     int `yaml:"io_pressure"`
+
+	// Growth of TCP sockets in CLOSE_WAIT and TIME_WAIT over 10 minutes
+	CloseWaitGrowth int `yaml:"close_wait_growth"`
+	TimeWaitGrowth  int `yaml:"time_wait_growth"`
+}
+
+// HistoryConfig controls how collected samples are kept on disk
+type HistoryConfig struct {
+	Interval      int `yaml:"interval"`       // Seconds between stored samples
+	RetentionDays int `yaml:"retention_days"` // Days of samples to keep
+}
+
+// PartitionsConfig selects the partitions reported by the disk collector.
+// Every entry is a regular expression matching the whole value. A partition
+// is reported if it matches any include pattern (or none are set) and no
+// exclude pattern. Setting a list replaces its defaults.
+type PartitionsConfig struct {
+	IncludeFSTypes     []string `yaml:"include_fstypes"`
+	ExcludeFSTypes     []string `yaml:"exclude_fstypes"`
+	IncludeDevices     []string `yaml:"include_devices"`
+	ExcludeDevices     []string `yaml:"exclude_devices"`
+	IncludeMountpoints []string `yaml:"include_mountpoints"`
+	ExcludeMountpoints []string `yaml:"exclude_mountpoints"`
+}
+
+// ListenersConfig lists the listening sockets expected to be up, e.g. tcp:22,
+// udp:53 or tcp:127.0.0.1:5432. tcp and udp cover IPv4 and IPv6.
+type ListenersConfig struct {
+	Expected []string `yaml:"expected"`
+}
+
+// ReportConfig describes one scheduled report
+type ReportConfig struct {
+	Name        string `yaml:"name"`
+	Schedule    string `yaml:"schedule"`     // Cron expression, e.g. "0 7 * * *"
+	WindowHours int    `yaml:"window_hours"` // 0 covers the time since the previous run
+	Template    string `yaml:"template"`     // Optional text/template or html/template file
+
+	Directory *DirectoryDelivery `yaml:"directory"`
+	Email     *EmailDelivery     `yaml:"email"`
+	HTTP      *HTTPDelivery      `yaml:"http"`
+}
+
+// DirectoryDelivery writes reports to a local directory
+type DirectoryDelivery struct {
+	Path          string `yaml:"path"`
+	RetentionDays int    `yaml:"retention_days"` // 0 keeps reports forever
+}
+
+// EmailDelivery sends reports through an SMTP server
+type EmailDelivery struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+// HTTPDelivery uploads reports to an HTTP endpoint
+type HTTPDelivery struct {
+	URL     string            `yaml:"url"`
+	Method  string            `yaml:"method"` // Defaults to POST
+	Headers map[string]string `yaml:"headers"`
+}
+
+// RemoteWriteConfig pushes metrics with the Prometheus remote_write protocol
+type RemoteWriteConfig struct {
+	URL            string            `yaml:"url"`
+	Headers        map[string]string `yaml:"headers"`
+	Username       string            `yaml:"username"` // Basic auth
+	Password       string            `yaml:"password"`
+	BearerToken    string            `yaml:"bearer_token"`
+	ExternalLabels map[string]string `yaml:"external_labels"` // Added to series lacking them, defaults to job and instance
+	BatchSize      int               `yaml:"batch_size"`      // Collection cycles per request
+	Timeout        int               `yaml:"timeout"`         // Seconds per request
+	MaxBackoff     int               `yaml:"max_backoff"`     // Maximum seconds between retries
+	WALMaxMB       int               `yaml:"wal_max_mb"`      // Oldest data is dropped past this size
+}
+
+// PushgatewayConfig pushes the registry to a Prometheus Pushgateway every cycle
+type PushgatewayConfig struct {
+	URL      string            `yaml:"url"`
+	Job      string            `yaml:"job"`      // Defaults to sys-monitor
+	Grouping map[string]string `yaml:"grouping"` // Grouping labels, defaults to instance
+	Username string            `yaml:"username"` // Basic auth
+	Password string            `yaml:"password"`
+	Timeout  int               `yaml:"timeout"` // Seconds per request
+}
+
+// InfluxDBConfig writes metrics in InfluxDB line protocol
+type InfluxDBConfig struct {
+	URL           string            `yaml:"url"` // http(s)://host:8086 for the v2 API or udp://host:8089
+	Org           string            `yaml:"org"`
+	Bucket        string            `yaml:"bucket"`
+	Token         string            `yaml:"token"`
+	Tags          map[string]string `yaml:"tags"`           // Added to every line, defaults to host
+	FieldLabel    string            `yaml:"field_label"`    // Label whose values become field keys, defaults to type
+	BatchSize     int               `yaml:"batch_size"`     // Lines per request
+	FlushInterval int               `yaml:"flush_interval"` // Seconds between flushes
+	BufferSize    int               `yaml:"buffer_size"`    // Lines kept while InfluxDB is unreachable
+	MaxRetries    *int              `yaml:"max_retries"`    // Defaults to 3, 0 disables retries
+	Gzip          bool              `yaml:"gzip"`
+	Timeout       int               `yaml:"timeout"` // Seconds per request
+}
+
+// GraphiteConfig sends metrics over the Graphite plaintext protocol
+type GraphiteConfig struct {
+	Address  string `yaml:"address"`  // host:port, e.g. graphite:2003
+	Protocol string `yaml:"protocol"` // tcp (default) or udp
+	Prefix   string `yaml:"prefix"`   // First path segments, defaults to sysmon
+	Host     string `yaml:"host"`     // Host path segment, defaults to the hostname
+}
+
+// StatsDConfig emits metrics as StatsD gauges and counters
+type StatsDConfig struct {
+	Address  string `yaml:"address"`  // host:port, e.g. statsd:8125
+	Protocol string `yaml:"protocol"` // udp (default) or tcp
+	Prefix   string `yaml:"prefix"`   // First path segments, defaults to sysmon
+	Host     string `yaml:"host"`     // Host path segment, defaults to the hostname
+}
+
+// OTLPConfig exports metrics to an OpenTelemetry collector
+type OTLPConfig struct {
+	Endpoint           string            `yaml:"endpoint"` // http://collector:4318 or collector:4317 for gRPC
+	Protocol           string            `yaml:"protocol"` // http/protobuf (default) or grpc
+	Insecure           bool              `yaml:"insecure"` // gRPC without TLS
+	Headers            map[string]string `yaml:"headers"`
+	ResourceAttributes map[string]string `yaml:"resource_attributes"` // Added to host.name, host.arch, os.type and service.name
+	Gzip               bool              `yaml:"gzip"`
+	Timeout            int               `yaml:"timeout"` // Seconds per export
+}
+
+// MetricsLogConfig appends every collection cycle to a rotating JSON Lines file
+type MetricsLogConfig struct {
+	Path          string `yaml:"path"`           // Defaults to data_dir/metrics.jsonl
+	MaxSizeMB     int    `yaml:"max_size_mb"`    // Rotate once the file is larger than this
+	MaxAgeHours   int    `yaml:"max_age_hours"`  // Rotate once the first record is older than this
+	RetentionDays int    `yaml:"retention_days"` // Rotated files older than this are deleted
+	MaxFiles      int    `yaml:"max_files"`      // 0 keeps any number of rotated files
+}
+
+// SyslogConfig forwards spike, alert and collector failure events as RFC 5424 messages
+type SyslogConfig struct {
+	Address  string `yaml:"address"`  // host:port, or the socket path for unixgram
+	Protocol string `yaml:"protocol"` // udp, tcp, tls or unixgram; /dev/log is used without an address
+	Facility string `yaml:"facility"` // daemon, user or local0 (default) to local7
+	AppName  string `yaml:"app_name"`
+	CAFile   string `yaml:"ca_file"` // CA certificates to verify the server with over tls
+}
+
+// LokiConfig pushes events and the agent's own log lines to Loki
+type LokiConfig struct {
+	URL           string            `yaml:"url"`       // e.g. http://loki:3100
+	TenantID      string            `yaml:"tenant_id"` // Sent as X-Scope-OrgID
+	Username      string            `yaml:"username"`
+	Password      string            `yaml:"password"`
+	Labels        map[string]string `yaml:"labels"`         // Added to job, host, collector, kind and severity
+	BatchSize     int               `yaml:"batch_size"`     // Log entries per push
+	FlushInterval int               `yaml:"flush_interval"` // Seconds
+	BufferSize    int               `yaml:"buffer_size"`    // Entries kept while Loki is unreachable
+	MaxRetries    *int              `yaml:"max_retries"`    // Defaults to 5, 0 disables retries
+	Timeout       int               `yaml:"timeout"`        // Seconds per push
 }
 
 func LoadConfig(path string) (Config, error) {
-	var config Config
+	config := Config{
+		DataDir: "data",
+		History: HistoryConfig{
+			Interval:      60,
+			RetentionDays: 35,
+		},
+		Partitions: PartitionsConfig{
+			// Pseudo, in-memory and read-only image file systems
+			ExcludeFSTypes: []string{
+				"autofs", "binfmt_misc", "bpf", "cgroup2?", "configfs", "debugfs",
+				"devpts", "devtmpfs", "efivarfs", "fuse\\.lxcfs", "fusectl", "hugetlbfs",
+				"iso9660", "mqueue", "nsfs", "overlay", "proc", "pstore", "ramfs",
+				"rpc_pipefs", "securityfs", "squashfs", "sysfs", "tmpfs", "tracefs",
+			},
+			ExcludeDevices:     []string{"/dev/loop[0-9]+"},
+			ExcludeMountpoints: []string{"/(dev|proc|sys|run|snap|var/lib/docker|var/lib/containers)(/.*)?"},
+		},
+	}
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return config, err
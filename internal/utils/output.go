@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"bufio"
+	"os"
+)
+
+// TeeStdout routes os.Stdout through a pipe so every line the agent prints is
+// also passed to handler, which must not block. The lines still reach the
+// original stdout. restore puts the original stdout back once all lines are
+// copied.
+func TeeStdout(handler func(line string)) (restore func(), err error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	original := os.Stdout
+	os.Stdout = w
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			line := scanner.Text()
+			original.WriteString(line + "\n")
+			if line != "" {
+				handler(line)
+			}
+		}
+	}()
+
+	return func() {
+		os.Stdout = original
+		w.Close()
+		<-done
+		r.Close()
+	}, nil
+}
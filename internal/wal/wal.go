@@ -0,0 +1,352 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	segmentSuffix  = ".wal"
+	checkpointName = "checkpoint"
+	headerSize     = 8 // uint32 length + uint32 CRC-32 of the payload
+
+	minSegmentSize = 64 << 10
+	maxSegmentSize = 16 << 20
+)
+
+// Position points at a record in the log
+type Position struct {
+	Segment int
+	Offset  int64
+}
+
+// WAL is a bounded, segmented on-disk queue of records. Records are appended
+// by producers and read back in order by a single consumer, which commits
+// its position once a batch has been delivered. When the log grows past its
+// size limit the oldest segments are dropped, even if they were not read.
+type WAL struct {
+	dir         string
+	maxBytes    int64
+	segmentSize int64
+
+	mu       sync.Mutex
+	segments []int // Segment indexes on disk, oldest first
+	sizes    map[int]int64
+	current  *os.File
+	read     Position // Committed consumer position
+	dropped  int64    // Bytes dropped to stay within maxBytes
+}
+
+// Open opens or creates a log in dir. A new segment is started on every open
+// so a record torn by a crash is never appended to.
+func Open(dir string, maxBytes int64) (*WAL, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating WAL directory: %v", err)
+	}
+
+	w := &WAL{
+		dir:         dir,
+		maxBytes:    maxBytes,
+		segmentSize: min(max(maxBytes/8, minSegmentSize), maxSegmentSize),
+		sizes:       make(map[int]int64),
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading WAL directory: %v", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimSuffix(name, segmentSuffix))
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("error reading WAL segment: %v", err)
+		}
+		w.segments = append(w.segments, index)
+		w.sizes[index] = info.Size()
+	}
+	sort.Ints(w.segments)
+
+	next := 1
+	if len(w.segments) > 0 {
+		next = w.segments[len(w.segments)-1] + 1
+	}
+
+	// A checkpoint past the last segment, e.g. after the segments were
+	// deleted by hand, would never be reached by new records
+	w.read = w.loadCheckpoint()
+	switch {
+	case len(w.segments) == 0 || w.read.Segment >= next:
+		w.read = Position{Segment: next}
+	case w.read.Segment < w.segments[0]:
+		w.read = Position{Segment: w.segments[0]}
+	}
+
+	if err := w.openSegment(next); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Append adds a record to the end of the log
+func (w *WAL) Append(record []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	index := w.segments[len(w.segments)-1]
+	if w.sizes[index] > 0 && w.sizes[index]+int64(headerSize+len(record)) > w.segmentSize {
+		if err := w.current.Close(); err != nil {
+			return fmt.Errorf("error closing WAL segment: %v", err)
+		}
+		index++
+		if err := w.openSegment(index); err != nil {
+			return err
+		}
+	}
+
+	buf := make([]byte, headerSize+len(record))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(record)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(record))
+	copy(buf[headerSize:], record)
+
+	if _, err := w.current.Write(buf); err != nil {
+		return fmt.Errorf("error writing WAL record: %v", err)
+	}
+	w.sizes[index] += int64(len(buf))
+
+	w.enforceLimit()
+	return nil
+}
+
+// Read returns up to maxRecords records after the committed position and
+// the position following the last one. Nothing is consumed until Commit.
+func (w *WAL) Read(maxRecords int) ([][]byte, Position, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	pos := w.read
+	var records [][]byte
+
+	for len(records) < maxRecords {
+		if pos.Offset >= w.sizes[pos.Segment] {
+			next, ok := w.nextSegment(pos.Segment)
+			if !ok {
+				break
+			}
+			pos = Position{Segment: next}
+			continue
+		}
+
+		segmentRecords, end, err := w.readSegment(pos, maxRecords-len(records))
+		records = append(records, segmentRecords...)
+		if err != nil {
+			// A corrupt or torn record ends the segment; skip what is left
+			fmt.Printf("Skipping corrupt WAL data in segment %d: %v\n", pos.Segment, err)
+			end = w.sizes[pos.Segment]
+		}
+		pos.Offset = end
+	}
+
+	return records, pos, nil
+}
+
+// Commit marks everything before pos as delivered and removes finished segments
+func (w *WAL) Commit(pos Position) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Segments dropped for size while the batch was in flight
+	if len(w.segments) > 0 && pos.Segment < w.segments[0] {
+		return nil
+	}
+
+	w.read = pos
+	current := w.segments[len(w.segments)-1]
+	for len(w.segments) > 1 && w.segments[0] < pos.Segment && w.segments[0] != current {
+		w.removeOldest()
+	}
+
+	return w.saveCheckpoint()
+}
+
+// Pending returns the number of bytes not yet committed
+func (w *WAL) Pending() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var pending int64
+	for _, index := range w.segments {
+		switch {
+		case index == w.read.Segment:
+			pending += w.sizes[index] - w.read.Offset
+		case index > w.read.Segment:
+			pending += w.sizes[index]
+		}
+	}
+	return pending
+}
+
+// Dropped returns the number of bytes discarded to stay within the size limit
+func (w *WAL) Dropped() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.dropped
+}
+
+// Close flushes the checkpoint and closes the current segment
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.saveCheckpoint(); err != nil {
+		return err
+	}
+	return w.current.Close()
+}
+
+func (w *WAL) openSegment(index int) error {
+	file, err := os.OpenFile(w.segmentPath(index), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening WAL segment: %v", err)
+	}
+
+	w.current = file
+	w.segments = append(w.segments, index)
+	w.sizes[index] = 0
+	return nil
+}
+
+// enforceLimit drops the oldest closed segments while the log is too large
+func (w *WAL) enforceLimit() {
+	if w.maxBytes <= 0 {
+		return
+	}
+
+	var total int64
+	for _, size := range w.sizes {
+		total += size
+	}
+
+	for total > w.maxBytes && len(w.segments) > 1 {
+		oldest := w.segments[0]
+		size := w.sizes[oldest]
+		fmt.Printf("WAL %s exceeds %d bytes, dropping segment %d\n", w.dir, w.maxBytes, oldest)
+
+		if w.read.Segment <= oldest {
+			w.dropped += size - w.read.Offset
+			w.read = Position{Segment: w.segments[1]}
+		}
+		w.removeOldest()
+		total -= size
+	}
+}
+
+func (w *WAL) removeOldest() {
+	oldest := w.segments[0]
+	if err := os.Remove(w.segmentPath(oldest)); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Error removing WAL segment %d: %v\n", oldest, err)
+	}
+	w.segments = w.segments[1:]
+	delete(w.sizes, oldest)
+}
+
+func (w *WAL) nextSegment(index int) (int, bool) {
+	for _, segment := range w.segments {
+		if segment > index {
+			return segment, true
+		}
+	}
+	return 0, false
+}
+
+// readSegment reads up to limit records starting at pos within one segment
+func (w *WAL) readSegment(pos Position, limit int) ([][]byte, int64, error) {
+	file, err := os.Open(w.segmentPath(pos.Segment))
+	if err != nil {
+		return nil, pos.Offset, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(pos.Offset, io.SeekStart); err != nil {
+		return nil, pos.Offset, err
+	}
+
+	reader := bufio.NewReader(io.LimitReader(file, w.sizes[pos.Segment]-pos.Offset))
+	offset := pos.Offset
+	var records [][]byte
+	header := make([]byte, headerSize)
+
+	for len(records) < limit && offset < w.sizes[pos.Segment] {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return records, offset, err
+		}
+
+		length := binary.BigEndian.Uint32(header[0:4])
+		if int64(length) > w.sizes[pos.Segment]-offset-headerSize {
+			return records, offset, errors.New("record length exceeds segment")
+		}
+
+		record := make([]byte, length)
+		if _, err := io.ReadFull(reader, record); err != nil {
+			return records, offset, err
+		}
+		if crc32.ChecksumIEEE(record) != binary.BigEndian.Uint32(header[4:8]) {
+			return records, offset, errors.New("checksum mismatch")
+		}
+
+		records = append(records, record)
+		offset += int64(headerSize) + int64(length)
+	}
+
+	return records, offset, nil
+}
+
+func (w *WAL) segmentPath(index int) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%08d%s", index, segmentSuffix))
+}
+
+func (w *WAL) loadCheckpoint() Position {
+	var pos Position
+
+	data, err := os.ReadFile(filepath.Join(w.dir, checkpointName))
+	if err != nil {
+		return pos
+	}
+	if _, err := fmt.Sscanf(string(data), "%d %d", &pos.Segment, &pos.Offset); err != nil {
+		return Position{}
+	}
+
+	return pos
+}
+
+func (w *WAL) saveCheckpoint() error {
+	path := filepath.Join(w.dir, checkpointName)
+	tmp := path + ".tmp"
+
+	data := fmt.Sprintf("%d %d\n", w.read.Segment, w.read.Offset)
+	if err := os.WriteFile(tmp, []byte(data), 0o644); err != nil {
+		return fmt.Errorf("error writing WAL checkpoint: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("error writing WAL checkpoint: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package wal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func appendAll(t *testing.T, w *WAL, records ...string) {
+	t.Helper()
+	for _, record := range records {
+		if err := w.Append([]byte(record)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readAll(t *testing.T, w *WAL) []string {
+	t.Helper()
+	records, pos, err := w.Read(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(pos); err != nil {
+		t.Fatal(err)
+	}
+
+	var result []string
+	for _, record := range records {
+		result = append(result, string(record))
+	}
+	return result
+}
+
+func assertRecords(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got records %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got records %q, want %q", got, want)
+		}
+	}
+}
+
+func TestReopenResumesAfterCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendAll(t, w, "a", "b")
+	assertRecords(t, readAll(t, w), "a", "b")
+	appendAll(t, w, "c")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err = Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	assertRecords(t, readAll(t, w), "c")
+}
+
+func TestCheckpointPastLastSegment(t *testing.T) {
+	for name, remove := range map[string]func(t *testing.T, dir string){
+		// Every segment deleted, the checkpoint kept
+		"no segments": func(t *testing.T, dir string) {
+			segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+			for _, segment := range segments {
+				if err := os.Remove(segment); err != nil {
+					t.Fatal(err)
+				}
+			}
+		},
+		// Only the newest segments deleted, so older ones remain below
+		// the checkpoint
+		"newest segments": func(t *testing.T, dir string) {
+			segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+			for _, segment := range segments[1:] {
+				if err := os.Remove(segment); err != nil {
+					t.Fatal(err)
+				}
+			}
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			// Every open starts a new segment, so the checkpoint ends up on
+			// the fourth one
+			for i := 0; i < 3; i++ {
+				w, err := Open(dir, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				appendAll(t, w, "old")
+				readAll(t, w)
+				if err := w.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := os.WriteFile(filepath.Join(dir, checkpointName), []byte("9 100"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			remove(t, dir)
+
+			w, err := Open(dir, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Close()
+
+			appendAll(t, w, "new")
+			assertRecords(t, readAll(t, w), "new")
+		})
+	}
+}